@@ -0,0 +1,375 @@
+package bench
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/rpc"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"uk.ac.bris.cs/gameoflife/broker"
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/soup"
+	"uk.ac.bris.cs/gameoflife/stubs"
+	"uk.ac.bris.cs/gameoflife/worker"
+)
+
+// Engines are the ways of computing turns that can be measured.
+// local runs gol.NextWorld in this process, tiled runs a gol.TiledWorld that skips stable tiles,
+// rpc sends the whole board to one worker every turn, and distributed sends every turn to a broker
+// that splits it between workers.
+var Engines = []string{"local", "tiled", "rpc", "distributed"}
+
+// Config describes a sweep. Every combination of size, thread count and engine is measured.
+type Config struct {
+	Sizes   []int    `json:"sizes"`   // widths and heights of the square boards
+	Threads []int    `json:"threads"` // goroutines for local and tiled, workers started in this process for distributed
+	Engines []string `json:"engines"`
+	Turns   int      `json:"turns"`  // turns timed in each repetition
+	Warmup  int      `json:"warmup"` // turns run before timing starts; the repetitions start from the world they end with
+	Reps    int      `json:"reps"`   // timed repetitions, each from the same world
+	Images  string   `json:"images"` // directory of <size>x<size>.pgm fixtures; other sizes get a random world
+	Worker  string   `json:"worker"` // address of the worker for rpc; empty starts one in this process
+	Broker  string   `json:"broker"` // address of the broker for distributed; empty starts one in this process
+}
+
+// DefaultConfig returns a sweep of the local engine over the fixtures in images/, followed by random worlds
+// of every power of two up to 8192.
+func DefaultConfig() Config {
+	return Config{
+		Sizes:   []int{16, 64, 128, 256, 512, 1024, 2048, 4096, 8192},
+		Threads: []int{1, 2, 4, 8},
+		Engines: []string{"local"},
+		Turns:   100,
+		Warmup:  10,
+		Reps:    3,
+		Images:  "images",
+	}
+}
+
+// Result is the speed of one engine on one board.
+type Result struct {
+	Engine  string    `json:"engine"`
+	Size    int       `json:"size"`
+	Threads int       `json:"threads"` // 0 when it is up to an external broker
+	Input   string    `json:"input"`   // fixture path, or the seed of a random world
+	Rates   []float64 `json:"turnsPerSec"`
+	Mean    float64   `json:"mean"`
+	Min     float64   `json:"min"`
+	Max     float64   `json:"max"`
+	StdDev  float64   `json:"stdDev"`
+}
+
+// Report is the outcome of a sweep along with what is needed to reproduce it.
+type Report struct {
+	Date      time.Time `json:"date"`
+	GoVersion string    `json:"goVersion"`
+	OS        string    `json:"os"`
+	Arch      string    `json:"arch"`
+	CPUs      int       `json:"cpus"`
+	Config    Config    `json:"config"`
+	Results   []Result  `json:"results"`
+}
+
+// Run measures every combination in config, printing each result as it is measured.
+func Run(config Config) (Report, error) {
+	report := Report{
+		Date:      time.Now().UTC(),
+		GoVersion: runtime.Version(),
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+		CPUs:      runtime.NumCPU(),
+		Config:    config,
+	}
+	if config.Turns < 1 || config.Reps < 1 {
+		return report, fmt.Errorf("turns and reps must be at least 1")
+	}
+	for _, name := range config.Engines {
+		if !known(name) {
+			return report, fmt.Errorf("unknown engine %q, expected one of %v", name, strings.Join(Engines, ", "))
+		}
+	}
+
+	fmt.Println(Header())
+	for _, size := range config.Sizes {
+		world, input, err := fixture(config, size)
+		if err != nil {
+			return report, err
+		}
+		for _, name := range config.Engines {
+			for _, threads := range threadCounts(config, name) {
+				result, err := measure(config, name, threads, world)
+				if err != nil {
+					return report, fmt.Errorf("%v %vx%v: %v", name, size, size, err)
+				}
+				result.Size = size
+				result.Input = input
+				fmt.Println(result)
+				report.Results = append(report.Results, result)
+			}
+		}
+	}
+	return report, nil
+}
+
+func known(name string) bool {
+	for _, engine := range Engines {
+		if engine == name {
+			return true
+		}
+	}
+	return false
+}
+
+// threadCounts returns the thread counts an engine is measured with. rpc always uses one worker,
+// and the size of an external broker's cluster cannot be changed from here.
+func threadCounts(config Config, name string) []int {
+	switch {
+	case name == "rpc":
+		return []int{1}
+	case name == "distributed" && config.Broker != "":
+		return []int{0}
+	}
+	return config.Threads
+}
+
+// fixture returns the starting world for a size: the image in config.Images if there is one,
+// otherwise a random world that is the same on every run.
+func fixture(config Config, size int) ([][]byte, string, error) {
+	if size < 1 {
+		return nil, "", fmt.Errorf("size %v must be at least 1", size)
+	}
+	path := filepath.Join(config.Images, fmt.Sprintf("%vx%v.pgm", size, size))
+	if _, err := os.Stat(path); err == nil {
+		world, err := gol.ReadPgm(path)
+		return world, path, err
+	}
+	seed := fmt.Sprintf("bench-%v", size)
+	world := soup.Generate(soup.Config{SoupSize: size, BoardSize: size}, seed)
+	return world, "random:" + seed, nil
+}
+
+// measure warms up an engine and then times config.Reps runs of config.Turns turns.
+func measure(config Config, name string, threads int, world [][]byte) (Result, error) {
+	result := Result{Engine: name, Threads: threads}
+	e, err := NewEngine(config, name, threads)
+	if err != nil {
+		return result, err
+	}
+	defer e.Close()
+
+	if config.Warmup > 0 {
+		if world, err = e.Run(world, config.Warmup); err != nil {
+			return result, err
+		}
+	}
+	for rep := 0; rep < config.Reps; rep++ {
+		start := time.Now()
+		if _, err := e.Run(world, config.Turns); err != nil {
+			return result, err
+		}
+		result.Rates = append(result.Rates, float64(config.Turns)/time.Since(start).Seconds())
+	}
+	result.summarise()
+	return result, nil
+}
+
+// summarise works out the mean, range and standard deviation of the rates.
+func (result *Result) summarise() {
+	result.Min, result.Max = math.Inf(1), math.Inf(-1)
+	sum := 0.0
+	for _, rate := range result.Rates {
+		sum += rate
+		result.Min = math.Min(result.Min, rate)
+		result.Max = math.Max(result.Max, rate)
+	}
+	result.Mean = sum / float64(len(result.Rates))
+	squares := 0.0
+	for _, rate := range result.Rates {
+		squares += (rate - result.Mean) * (rate - result.Mean)
+	}
+	result.StdDev = math.Sqrt(squares / float64(len(result.Rates)))
+}
+
+// Header returns the column titles for Result.String.
+func Header() string {
+	return fmt.Sprintf("%-12v %-10v %7v %12v %12v %12v %10v", "engine", "size", "threads", "turns/sec", "min", "max", "stddev")
+}
+
+func (result Result) String() string {
+	return fmt.Sprintf("%-12v %-10v %7v %12.1f %12.1f %12.1f %10.1f",
+		result.Engine, fmt.Sprintf("%vx%v", result.Size, result.Size), threadsString(result.Threads),
+		result.Mean, result.Min, result.Max, result.StdDev)
+}
+
+func threadsString(threads int) string {
+	if threads == 0 {
+		return "-"
+	}
+	return strconv.Itoa(threads)
+}
+
+// Engine computes turns of a world.
+type Engine interface {
+	Run(world [][]byte, turns int) ([][]byte, error)
+	Close()
+}
+
+// NewEngine starts one of Engines. threads is the number of goroutines for local and tiled and of workers for
+// distributed. The rpc and distributed engines connect to config.Worker and config.Broker, or to
+// servers started in this process if those are empty.
+func NewEngine(config Config, name string, threads int) (Engine, error) {
+	if !known(name) {
+		return nil, fmt.Errorf("unknown engine %q, expected one of %v", name, strings.Join(Engines, ", "))
+	}
+	switch name {
+	case "local":
+		return localEngine{gol.Params{Threads: threads}}, nil
+	case "tiled":
+		return tiledEngine{threads}, nil
+	}
+	addr, stop := config.Worker, func() {}
+	if name == "distributed" {
+		addr = config.Broker
+	}
+	if addr == "" {
+		var err error
+		if name == "rpc" {
+			addr, stop, err = startWorker()
+		} else {
+			addr, stop, err = startBroker(threads)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	client, err := rpc.Dial("tcp", addr)
+	if err != nil {
+		stop()
+		return nil, err
+	}
+	if name == "rpc" {
+		return rpcEngine{remote{client, stop}}, nil
+	}
+	return brokerEngine{remote{client, stop}}, nil
+}
+
+type localEngine struct {
+	p gol.Params
+}
+
+func (e localEngine) Run(world [][]byte, turns int) ([][]byte, error) {
+	for turn := 0; turn < turns; turn++ {
+		world = gol.NextWorld(world, e.p)
+	}
+	return world, nil
+}
+
+func (e localEngine) Close() {}
+
+type tiledEngine struct {
+	threads int
+}
+
+func (e tiledEngine) Run(world [][]byte, turns int) ([][]byte, error) {
+	// The world is copied, as a TiledWorld updates it in place.
+	start := make([][]byte, len(world))
+	for y := range world {
+		start[y] = append([]byte(nil), world[y]...)
+	}
+	tiles := gol.NewTiledWorld(start, e.threads)
+	for turn := 0; turn < turns; turn++ {
+		tiles.Next()
+	}
+	return tiles.World(), nil
+}
+
+func (e tiledEngine) Close() {}
+
+// remote is a connection to a worker or broker, and how to stop it if it was started here.
+type remote struct {
+	client *rpc.Client
+	stop   func()
+}
+
+func (r remote) Close() {
+	r.client.Close()
+	r.stop()
+}
+
+// rpcEngine asks one worker for the whole board every turn.
+type rpcEngine struct {
+	remote
+}
+
+func (e rpcEngine) Run(world [][]byte, turns int) ([][]byte, error) {
+	for turn := 0; turn < turns; turn++ {
+		res := new(stubs.StripResponse)
+		req := stubs.StripRequest{World: world, StartY: 0, EndY: len(world)}
+		if err := e.client.Call(stubs.NextStripHandler, req, res); err != nil {
+			return nil, err
+		}
+		world = res.Rows
+	}
+	return world, nil
+}
+
+// brokerEngine sends all the turns to a broker in one call.
+type brokerEngine struct {
+	remote
+}
+
+func (e brokerEngine) Run(world [][]byte, turns int) ([][]byte, error) {
+	res := new(stubs.TurnsResponse)
+	err := e.client.Call(stubs.BrokerTurnsHandler, stubs.TurnsRequest{World: world, Turns: turns}, res)
+	return res.World, err
+}
+
+// startWorker serves a worker on a loopback port.
+func startWorker() (string, func(), error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", nil, err
+	}
+	go worker.Serve(listener)
+	return listener.Addr().String(), func() { listener.Close() }, nil
+}
+
+// startBroker serves a broker and its workers on loopback ports.
+func startBroker(workers int) (string, func(), error) {
+	var addrs []string
+	var stops []func()
+	stop := func() {
+		for i := len(stops) - 1; i >= 0; i-- {
+			stops[i]()
+		}
+	}
+	for i := 0; i < workers; i++ {
+		addr, stopWorker, err := startWorker()
+		if err != nil {
+			stop()
+			return "", nil, err
+		}
+		addrs = append(addrs, addr)
+		stops = append(stops, stopWorker)
+	}
+	b, err := broker.New(addrs)
+	if err != nil {
+		stop()
+		return "", nil, err
+	}
+	stops = append(stops, b.Close)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		stop()
+		return "", nil, err
+	}
+	stops = append(stops, func() { listener.Close() })
+	go broker.Serve(listener, b)
+	return listener.Addr().String(), stop, nil
+}
@@ -0,0 +1,115 @@
+package bench
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Save writes the report in the format given by the file extension: .json, which Load can read
+// back as a baseline, or .csv with one row per result.
+func (report Report) Save(path string) error {
+	var write func(io.Writer) error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		write = func(w io.Writer) error {
+			encoder := json.NewEncoder(w)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(report)
+		}
+	case ".csv":
+		write = report.WriteCSV
+	default:
+		return fmt.Errorf("%v: unknown format, expected .json or .csv", path)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = write(file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// WriteCSV writes one row per result, with a header row.
+func (report Report) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	_ = writer.Write([]string{"engine", "size", "threads", "input", "turns", "reps", "mean", "min", "max", "stddev"})
+	for _, result := range report.Results {
+		_ = writer.Write([]string{
+			result.Engine,
+			strconv.Itoa(result.Size),
+			strconv.Itoa(result.Threads),
+			result.Input,
+			strconv.Itoa(report.Config.Turns),
+			strconv.Itoa(len(result.Rates)),
+			formatRate(result.Mean),
+			formatRate(result.Min),
+			formatRate(result.Max),
+			formatRate(result.StdDev),
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func formatRate(rate float64) string {
+	return strconv.FormatFloat(rate, 'f', 2, 64)
+}
+
+// Load reads a report saved as JSON.
+func Load(path string) (Report, error) {
+	var report Report
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return report, err
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		return report, fmt.Errorf("%v: %v", path, err)
+	}
+	return report, nil
+}
+
+// Compare lists the change in mean turns/sec of every result that is also in baseline.
+// Changes bigger than tolerance, such as 0.05 for 5%, are marked as faster or slower.
+func Compare(baseline, current Report, tolerance float64) string {
+	type key struct {
+		engine        string
+		size, threads int
+	}
+	before := make(map[key]Result)
+	for _, result := range baseline.Results {
+		before[key{result.Engine, result.Size, result.Threads}] = result
+	}
+
+	var output []string
+	output = append(output, fmt.Sprintf("Compared with the baseline from %v (%v, %v CPUs)\n",
+		baseline.Date.Format("2006-01-02 15:04"), baseline.GoVersion, baseline.CPUs))
+	output = append(output, fmt.Sprintf("%-12v %-10v %7v %12v %12v %8v\n", "engine", "size", "threads", "before", "after", "change"))
+	for _, result := range current.Results {
+		old, ok := before[key{result.Engine, result.Size, result.Threads}]
+		if !ok || old.Mean == 0 {
+			output = append(output, fmt.Sprintf("%-12v %-10v %7v %12v %12.1f %8v\n",
+				result.Engine, fmt.Sprintf("%vx%v", result.Size, result.Size), threadsString(result.Threads), "-", result.Mean, "new"))
+			continue
+		}
+		change := result.Mean/old.Mean - 1
+		verdict := ""
+		if change > tolerance {
+			verdict = "faster"
+		} else if change < -tolerance {
+			verdict = "slower"
+		}
+		output = append(output, fmt.Sprintf("%-12v %-10v %7v %12.1f %12.1f %+7.1f%% %v\n",
+			result.Engine, fmt.Sprintf("%vx%v", result.Size, result.Size), threadsString(result.Threads),
+			old.Mean, result.Mean, 100*change, verdict))
+	}
+	return strings.Join(output, "")
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/bench"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestBench runs a small sweep of every engine and checks that its report survives a round trip as a baseline,
+// and that a report is not saved in an unknown format.
+func TestBench(t *testing.T) {
+	config := bench.DefaultConfig()
+	config.Sizes = []int{16, 20}
+	config.Threads = []int{1, 2}
+	config.Engines = bench.Engines
+	config.Turns = 5
+	config.Warmup = 1
+	config.Reps = 2
+	report, err := bench.Run(config)
+	util.Check(err)
+
+	// local, tiled and distributed are measured with each thread count, rpc only with one worker.
+	assert(t, len(report.Results) == 14, "Sweep gave %v results, expected 14\n", len(report.Results))
+	for _, result := range report.Results {
+		assert(t, len(result.Rates) == 2 && result.Min > 0 && result.Min <= result.Mean && result.Mean <= result.Max,
+			"Result %v has %v rates, expected 2 with min <= mean <= max\n", result, len(result.Rates))
+	}
+	assert(t, report.Results[0].Input == filepath.Join("images", "16x16.pgm"), "16x16 read from %v, expected the fixture\n", report.Results[0].Input)
+	assert(t, report.Results[9].Input == "random:bench-20", "20x20 read from %v, expected a random world\n", report.Results[9].Input)
+
+	dir := t.TempDir()
+	util.Check(report.Save(filepath.Join(dir, "report.json")))
+	baseline, err := bench.Load(filepath.Join(dir, "report.json"))
+	util.Check(err)
+	comparison := bench.Compare(baseline, report, 0.05)
+	assert(t, strings.Count(comparison, "+0.0%") == 14, "Comparing a report with itself gave\n%v", comparison)
+
+	util.Check(report.Save(filepath.Join(dir, "report.csv")))
+	data, err := os.ReadFile(filepath.Join(dir, "report.csv"))
+	util.Check(err)
+	assert(t, strings.Count(string(data), "\n") == 15, "CSV report has %v lines, expected a header and 14 rows\n", strings.Count(string(data), "\n"))
+
+	err = report.Save(filepath.Join(dir, "report.txt"))
+	_, statErr := os.Stat(filepath.Join(dir, "report.txt"))
+	assert(t, err != nil && os.IsNotExist(statErr), "Saving as .txt gave error %v and left a file behind\n", err)
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestBreakpoints checks that breakpoints are parsed, that cells off the board are rejected, that a turn
+// breakpoint pauses the run after reporting itself and that snapshots are saved every few turns.
+func TestBreakpoints(t *testing.T) {
+	for _, condition := range []string{"turn=7", "population<20", "cell=3,4"} {
+		breakpoint, err := gol.ParseBreakpoint(condition)
+		assert(t, err == nil && breakpoint.String() == condition, "Parsed %q as %v, error %v\n", condition, breakpoint, err)
+	}
+	_, err := gol.ParseBreakpoint("cell=3")
+	assert(t, err != nil, "Parsed cell=3 without an error\n")
+
+	for _, condition := range []string{"cell=16,3", "cell=3,16", "cell=-1,0"} {
+		breakpoint, err := gol.ParseBreakpoint(condition)
+		util.Check(err)
+		p := gol.Params{ImageWidth: 16, ImageHeight: 16, Breakpoints: []gol.Breakpoint{breakpoint}}
+		assert(t, p.CheckBreakpoints() != nil, "Accepted %v on a 16x16 board\n", condition)
+		p.Unbounded = true
+		assert(t, p.CheckBreakpoints() == nil, "Rejected %v in an unbounded world\n", condition)
+	}
+
+	breakpoint, _ := gol.ParseBreakpoint("turn=7")
+	p := gol.Params{
+		Turns:         20,
+		Threads:       2,
+		ImageWidth:    16,
+		ImageHeight:   16,
+		SnapshotEvery: 4,
+		Breakpoints:   []gol.Breakpoint{breakpoint},
+	}
+	events := make(chan gol.Event, 1000)
+	commands := make(chan gol.Command)
+	go gol.RunCommands(p, events, commands)
+
+	var saved []int
+	var hit *gol.BreakpointHit
+	for event := range events {
+		switch e := event.(type) {
+		case gol.BreakpointHit:
+			hit = &e
+		case gol.StateChange:
+			if e.NewState == gol.Paused {
+				assert(t, e.CompletedTurns == 7, "Paused at turn %v, expected 7\n", e.CompletedTurns)
+				assert(t, hit != nil && hit.CompletedTurns == 7 && hit.Breakpoint == breakpoint,
+					"Paused after BreakpointHit %+v, expected turn=7 at turn 7\n", hit)
+				ack := gol.Controller(commands).Do(gol.Resume)
+				assert(t, ack.Err == nil && ack.State == gol.Executing, "Resume acknowledged with %+v\n", ack)
+			}
+		case gol.ImageOutputComplete:
+			saved = append(saved, e.CompletedTurns)
+		}
+	}
+	expected := []int{4, 8, 12, 16, 20}
+	assert(t, len(saved) == len(expected), "Saved images at turns %v, expected %v\n", saved, expected)
+	for i := 0; i < len(saved) && i < len(expected); i++ {
+		assert(t, saved[i] == expected[i], "Saved images at turns %v, expected %v\n", saved, expected)
+	}
+}
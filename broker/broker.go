@@ -0,0 +1,92 @@
+package broker
+
+import (
+	"fmt"
+	"net"
+	"net/rpc"
+
+	"uk.ac.bris.cs/gameoflife/stubs"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// Broker runs turns by splitting the world into one horizontal strip per worker.
+type Broker struct {
+	workers []*rpc.Client
+}
+
+// New connects to every worker address. It fails if any worker cannot be reached.
+func New(addrs []string) (*Broker, error) {
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("no workers given")
+	}
+	b := &Broker{}
+	for _, addr := range addrs {
+		client, err := rpc.Dial("tcp", addr)
+		if err != nil {
+			b.Close()
+			return nil, fmt.Errorf("worker %v: %v", addr, err)
+		}
+		b.workers = append(b.workers, client)
+	}
+	return b, nil
+}
+
+// Turns runs req.Turns turns of req.World on the workers.
+func (b *Broker) Turns(req stubs.TurnsRequest, res *stubs.TurnsResponse) error {
+	world := req.World
+	for turn := 0; turn < req.Turns; turn++ {
+		next, err := b.Next(world)
+		if err != nil {
+			return fmt.Errorf("turn %v: %v", turn+1, err)
+		}
+		world = next
+	}
+	res.World = world
+	res.CompletedTurns = req.Turns
+	return nil
+}
+
+// Next computes one turn, asking every worker for its strip at the same time.
+func (b *Broker) Next(world [][]uint8) ([][]uint8, error) {
+	height := len(world)
+	strips := len(b.workers)
+	if strips > height {
+		strips = height
+	}
+	calls := make([]*rpc.Call, strips)
+	for i := range calls {
+		req := stubs.StripRequest{World: world, StartY: i * height / strips, EndY: (i + 1) * height / strips}
+		calls[i] = b.workers[i].Go(stubs.NextStripHandler, req, new(stubs.StripResponse), nil)
+	}
+	next := make([][]uint8, 0, height)
+	var err error
+	for _, call := range calls {
+		<-call.Done
+		if call.Error != nil {
+			err = call.Error
+			continue
+		}
+		next = append(next, call.Reply.(*stubs.StripResponse).Rows...)
+	}
+	return next, err
+}
+
+// Close disconnects from the workers.
+func (b *Broker) Close() {
+	for _, worker := range b.workers {
+		worker.Close()
+	}
+}
+
+// Serve registers b and answers RPC calls on listener until it is closed.
+func Serve(listener net.Listener, b *Broker) {
+	server := rpc.NewServer()
+	util.Check(server.Register(b))
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		go server.ServeConn(conn)
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestBus tests that subscribers of a gol.Bus get the events they asked for under each policy.
+func TestBus(t *testing.T) {
+	t.Run("filter", testBusFilter)
+	t.Run("drop-oldest", testBusDropOldest)
+	t.Run("coalesce", testBusCoalesce)
+	t.Run("coalesce-flips", testBusCoalesceFlips)
+}
+
+func testBusFilter(t *testing.T) {
+	bus := gol.NewBus()
+	all := bus.Subscribe(gol.Block, 10)
+	counts := bus.Subscribe(gol.Block, 10, gol.AliveCellsCount{})
+
+	bus.Publish(gol.TurnComplete{CompletedTurns: 1})
+	bus.Publish(gol.AliveCellsCount{CompletedTurns: 1, CellsCount: 5})
+	bus.Publish(gol.TurnComplete{CompletedTurns: 2})
+	bus.Close()
+
+	received := drainSubscription(all)
+	assert(t, len(received) == 3, "Unfiltered subscriber received %v events, expected 3\n", len(received))
+	received = drainSubscription(counts)
+	assert(t, len(received) == 1, "Filtered subscriber received %v events, expected 1\n", len(received))
+	if len(received) == 1 {
+		_, ok := received[0].(gol.AliveCellsCount)
+		assert(t, ok, "Filtered subscriber received %T, expected gol.AliveCellsCount\n", received[0])
+	}
+}
+
+func testBusDropOldest(t *testing.T) {
+	bus := gol.NewBus()
+	slow := bus.Subscribe(gol.DropOldest, 2)
+
+	// Let the subscription take the first event, so it is held rather than queued.
+	bus.Publish(gol.TurnComplete{CompletedTurns: 1})
+	time.Sleep(10 * time.Millisecond)
+	for turn := 2; turn <= 5; turn++ {
+		bus.Publish(gol.TurnComplete{CompletedTurns: turn})
+	}
+	bus.Close()
+
+	received := drainSubscription(slow)
+	assert(t, slow.Dropped() == 2, "Dropped %v events, expected 2\n", slow.Dropped())
+	assert(t, len(received) == 3, "Received %v events, expected 3\n", len(received))
+	if len(received) == 3 {
+		assert(t, received[2].GetCompletedTurns() == 5, "Last event was for turn %v, expected 5\n", received[2].GetCompletedTurns())
+	}
+}
+
+func testBusCoalesce(t *testing.T) {
+	bus := gol.NewBus()
+	slow := bus.Subscribe(gol.Coalesce, 1)
+
+	bus.Publish(gol.AliveCellsCount{CompletedTurns: 1, CellsCount: 1})
+	time.Sleep(10 * time.Millisecond)
+	for turn := 2; turn <= 5; turn++ {
+		bus.Publish(gol.AliveCellsCount{CompletedTurns: turn, CellsCount: turn})
+	}
+	bus.Close()
+
+	received := drainSubscription(slow)
+	assert(t, len(received) == 2, "Received %v events, expected 2\n", len(received))
+	if len(received) == 2 {
+		last := received[1].(gol.AliveCellsCount)
+		assert(t, last.CellsCount == 5, "Coalesced count is %v, expected the latest count 5\n", last.CellsCount)
+	}
+}
+
+func drainSubscription(s *gol.Subscription) []gol.Event {
+	var received []gol.Event
+	for event := range s.Events() {
+		received = append(received, event)
+	}
+	return received
+}
+
+// testBusCoalesceFlips checks that a slow subscriber's board is correct at every TurnComplete
+// it receives, even though flips from several turns have been merged.
+func testBusCoalesceFlips(t *testing.T) {
+	bus := gol.NewBus()
+	slow := bus.Subscribe(gol.Coalesce, 1)
+
+	a, b, c, d := util.Cell{X: 1, Y: 1}, util.Cell{X: 2, Y: 2}, util.Cell{X: 3, Y: 3}, util.Cell{X: 4, Y: 4}
+	turns := [][]util.Cell{{a, b}, {a, c}, {d}, {}, {b}}
+	expected := make([]map[util.Cell]bool, len(turns)+1)
+	world := map[util.Cell]bool{}
+	for turn, flips := range turns {
+		for _, cell := range flips {
+			if world[cell] {
+				delete(world, cell)
+			} else {
+				world[cell] = true
+			}
+			bus.Publish(gol.CellFlipped{CompletedTurns: turn + 1, Cell: cell})
+		}
+		bus.Publish(gol.TurnComplete{CompletedTurns: turn + 1})
+		expected[turn+1] = make(map[util.Cell]bool)
+		for cell := range world {
+			expected[turn+1][cell] = true
+		}
+	}
+	bus.Close()
+
+	received := drainSubscription(slow)
+	assert(t, len(received) < 3*len(turns), "Received %v events, expected flips to be merged\n", len(received))
+	board := map[util.Cell]bool{}
+	lastTurn := 0
+	for _, event := range received {
+		switch e := event.(type) {
+		case gol.CellFlipped:
+			board[e.Cell] = !board[e.Cell]
+		case gol.CellsFlipped:
+			for _, cell := range e.Cells {
+				board[cell] = !board[cell]
+			}
+		case gol.TurnComplete:
+			lastTurn = e.CompletedTurns
+			for cell, alive := range board {
+				assert(t, alive == expected[e.CompletedTurns][cell], "Cell %v wrong at turn %v\n", cell, e.CompletedTurns)
+			}
+			for cell := range expected[e.CompletedTurns] {
+				assert(t, board[cell], "Cell %v should be alive at turn %v\n", cell, e.CompletedTurns)
+			}
+		}
+	}
+	assert(t, lastTurn == len(turns), "Last TurnComplete was for turn %v, expected %v\n", lastTurn, len(turns))
+}
@@ -0,0 +1,122 @@
+package census
+
+import (
+	"fmt"
+	"strings"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// Kind is the broad class of a recognised object.
+type Kind int
+
+const (
+	Unknown Kind = iota
+	StillLife
+	Oscillator
+	Spaceship
+)
+
+func (kind Kind) String() string {
+	switch kind {
+	case StillLife:
+		return "Still life"
+	case Oscillator:
+		return "Oscillator"
+	case Spaceship:
+		return "Spaceship"
+	default:
+		return "Unknown"
+	}
+}
+
+// entry describes one catalogue object in plaintext format, with rows separated by '/'.
+type entry struct {
+	name    string
+	kind    Kind
+	period  int
+	pattern string
+}
+
+var entries = []entry{
+	{"block", StillLife, 1, "OO/OO"},
+	{"beehive", StillLife, 1, ".OO./O..O/.OO."},
+	{"loaf", StillLife, 1, ".OO./O..O/.O.O/..O."},
+	{"boat", StillLife, 1, "OO./O.O/.O."},
+	{"ship", StillLife, 1, "OO./O.O/.OO"},
+	{"tub", StillLife, 1, ".O./O.O/.O."},
+	{"pond", StillLife, 1, ".OO./O..O/O..O/.OO."},
+	{"long boat", StillLife, 1, "OO../O.O./.O.O/..O."},
+	{"barge", StillLife, 1, ".O../O.O./.O.O/..O."},
+	{"mango", StillLife, 1, ".OO../O..O./.O..O/..OO."},
+	{"eater 1", StillLife, 1, "OO../O.O./..O./..OO"},
+	{"aircraft carrier", StillLife, 1, "OO../O..O/..OO"},
+	{"blinker", Oscillator, 2, "OOO"},
+	{"toad", Oscillator, 2, ".OOO/OOO."},
+	{"beacon", Oscillator, 2, "OO../OO../..OO/..OO"},
+	{"pulsar", Oscillator, 3, "..OOO...OOO../............./O....O.O....O/O....O.O....O/O....O.O....O/..OOO...OOO../............./..OOO...OOO../O....O.O....O/O....O.O....O/O....O.O....O/............./..OOO...OOO.."},
+	{"glider", Spaceship, 4, ".O./..O/OOO"},
+	{"LWSS", Spaceship, 4, ".O..O/O..../O...O/OOOO."},
+	{"MWSS", Spaceship, 4, "...O../.O...O/O...../O....O/OOOOO."},
+	{"HWSS", Spaceship, 4, "...OO../.O....O/O....../O.....O/OOOOOO."},
+}
+
+// catalogue maps the canonical code of every phase of every entry to the entry.
+var catalogue = buildCatalogue()
+
+func buildCatalogue() map[string]entry {
+	catalogue := make(map[string]entry)
+	for _, e := range entries {
+		phase := parsePlaintext(e.pattern)
+		for i := 0; i < e.period; i++ {
+			catalogue[Canonical(phase)] = e
+			phase = step(phase)
+		}
+	}
+	return catalogue
+}
+
+// parsePlaintext reads a pattern with rows separated by '/', 'O' for alive and '.' for dead.
+func parsePlaintext(pattern string) []util.Cell {
+	var cells []util.Cell
+	for y, row := range strings.Split(pattern, "/") {
+		for x, c := range row {
+			if c == 'O' {
+				cells = append(cells, util.Cell{X: x, Y: y})
+			}
+		}
+	}
+	return cells
+}
+
+// step advances an unbounded pattern by one turn of B3/S23.
+func step(cells []util.Cell) []util.Cell {
+	alive := make(map[util.Cell]bool, len(cells))
+	neighbours := make(map[util.Cell]int, 8*len(cells))
+	for _, c := range cells {
+		alive[c] = true
+		for dy := -1; dy <= 1; dy++ {
+			for dx := -1; dx <= 1; dx++ {
+				if dx != 0 || dy != 0 {
+					neighbours[util.Cell{X: c.X + dx, Y: c.Y + dy}]++
+				}
+			}
+		}
+	}
+	var next []util.Cell
+	for c, n := range neighbours {
+		if n == 3 || (n == 2 && alive[c]) {
+			next = append(next, c)
+		}
+	}
+	return next
+}
+
+// describe returns the catalogue name and kind for a canonical code.
+// Unrecognised objects are named by their cell count, e.g. "unknown 12".
+func describe(code string, size int) (string, Kind, int) {
+	if e, ok := catalogue[code]; ok {
+		return e.name, e.kind, e.period
+	}
+	return fmt.Sprintf("unknown %v", size), Unknown, 0
+}
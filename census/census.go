@@ -0,0 +1,218 @@
+package census
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// Object is one separate pattern found in the world.
+// Cells are in world coordinates, unwrapped so that an object crossing the edge of the torus
+// is contiguous; some may therefore lie outside the board.
+type Object struct {
+	Name   string
+	Kind   Kind
+	Period int
+	Code   string
+	Cells  []util.Cell
+}
+
+// Objects splits the alive cells of a width x height torus into objects and classifies each one.
+//
+// Cells are first grouped by 8-connectivity. Some objects, such as the LWSS or the second phase of
+// a beacon, are not connected, so any groups not in the catalogue are merged with other
+// unrecognised groups within two cells and classified again.
+func Objects(alive []util.Cell, width, height int) []Object {
+	grid := make(map[util.Cell]bool, len(alive))
+	for _, c := range alive {
+		grid[c] = true
+	}
+
+	var objects []Object
+	var unknown [][]util.Cell
+	seen := make(map[util.Cell]bool, len(alive))
+	for _, start := range alive {
+		if seen[start] {
+			continue
+		}
+		group := component(start, grid, seen, 1, width, height)
+		code := Canonical(group)
+		if _, ok := catalogue[code]; ok {
+			objects = append(objects, newObject(group, code))
+		} else {
+			unknown = append(unknown, group)
+		}
+	}
+
+	// Regroup the unrecognised cells using a wider neighbourhood.
+	unknownGrid := make(map[util.Cell]bool)
+	var unknownCells []util.Cell
+	for _, group := range unknown {
+		for _, c := range group {
+			wrapped := wrap(c, width, height)
+			unknownGrid[wrapped] = true
+			unknownCells = append(unknownCells, wrapped)
+		}
+	}
+	seen = make(map[util.Cell]bool, len(unknownCells))
+	for _, start := range unknownCells {
+		if seen[start] {
+			continue
+		}
+		group := component(start, unknownGrid, seen, 2, width, height)
+		objects = append(objects, newObject(group, Canonical(group)))
+	}
+	return objects
+}
+
+// Count returns the number of objects of each name in the world.
+func Count(alive []util.Cell, width, height int) map[string]int {
+	counts := make(map[string]int)
+	for _, object := range Objects(alive, width, height) {
+		counts[object.Name]++
+	}
+	return counts
+}
+
+// Report formats counts as a table, most common first.
+func Report(counts map[string]int) string {
+	names := make([]string, 0, len(counts))
+	total := 0
+	for name, count := range counts {
+		names = append(names, name)
+		total += count
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if counts[names[i]] != counts[names[j]] {
+			return counts[names[i]] > counts[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	var output []string
+	for _, name := range names {
+		output = append(output, fmt.Sprintf("%-20v %v\n", name, counts[name]))
+	}
+	output = append(output, fmt.Sprintf("%-20v %v\n", "Total", total))
+	return strings.Join(output, "")
+}
+
+func newObject(cells []util.Cell, code string) Object {
+	name, kind, period := describe(code, len(cells))
+	return Object{Name: name, Kind: kind, Period: period, Code: code, Cells: cells}
+}
+
+// component collects every cell reachable from start through cells at most reach apart,
+// wrapping around the edges of the board. Cells are returned unwrapped relative to start.
+func component(start util.Cell, grid, seen map[util.Cell]bool, reach, width, height int) []util.Cell {
+	seen[start] = true
+	cells := []util.Cell{start}
+	for i := 0; i < len(cells); i++ {
+		current := cells[i]
+		for dy := -reach; dy <= reach; dy++ {
+			for dx := -reach; dx <= reach; dx++ {
+				next := util.Cell{X: current.X + dx, Y: current.Y + dy}
+				wrapped := wrap(next, width, height)
+				if (dx == 0 && dy == 0) || !grid[wrapped] || seen[wrapped] {
+					continue
+				}
+				seen[wrapped] = true
+				cells = append(cells, next)
+			}
+		}
+	}
+	return cells
+}
+
+func wrap(c util.Cell, width, height int) util.Cell {
+	return util.Cell{X: (c.X%width + width) % width, Y: (c.Y%height + height) % height}
+}
+
+// Canonical returns a code for a pattern that is the same under any translation, rotation or
+// reflection. The code is the pattern in plaintext format with rows separated by '/', choosing
+// whichever of the eight orientations sorts first.
+func Canonical(cells []util.Cell) string {
+	best := ""
+	for t := 0; t < 8; t++ {
+		transformed := make([]util.Cell, len(cells))
+		for i, c := range cells {
+			x, y := c.X, c.Y
+			if t&1 != 0 {
+				x = -x
+			}
+			if t&2 != 0 {
+				y = -y
+			}
+			if t&4 != 0 {
+				x, y = y, x
+			}
+			transformed[i] = util.Cell{X: x, Y: y}
+		}
+		if code := plaintext(transformed); best == "" || code < best {
+			best = code
+		}
+	}
+	return best
+}
+
+// plaintext draws cells, translated to the origin, as rows of 'O' and '.' separated by '/'.
+func plaintext(cells []util.Cell) string {
+	if len(cells) == 0 {
+		return ""
+	}
+	minX, minY, maxX, maxY := cells[0].X, cells[0].Y, cells[0].X, cells[0].Y
+	for _, c := range cells {
+		if c.X < minX {
+			minX = c.X
+		}
+		if c.X > maxX {
+			maxX = c.X
+		}
+		if c.Y < minY {
+			minY = c.Y
+		}
+		if c.Y > maxY {
+			maxY = c.Y
+		}
+	}
+	rows := make([][]byte, maxY-minY+1)
+	for i := range rows {
+		rows[i] = []byte(strings.Repeat(".", maxX-minX+1))
+	}
+	for _, c := range cells {
+		rows[c.Y-minY][c.X-minX] = 'O'
+	}
+	lines := make([]string, len(rows))
+	for i, row := range rows {
+		lines[i] = string(row)
+	}
+	return strings.Join(lines, "/")
+}
+
+// Velocity returns how far a spaceship moves each turn, found by running it for one period.
+// It returns zero for anything that is not a spaceship.
+func Velocity(object Object) (vx, vy float64) {
+	if object.Kind != Spaceship {
+		return 0, 0
+	}
+	cells := object.Cells
+	for i := 0; i < object.Period; i++ {
+		cells = step(cells)
+	}
+	startX, startY := Centre(object.Cells)
+	endX, endY := Centre(cells)
+	return (endX - startX) / float64(object.Period), (endY - startY) / float64(object.Period)
+}
+
+// Centre returns the mean position of the cells.
+func Centre(cells []util.Cell) (x, y float64) {
+	if len(cells) == 0 {
+		return 0, 0
+	}
+	for _, c := range cells {
+		x += float64(c.X)
+		y += float64(c.Y)
+	}
+	return x / float64(len(cells)), y / float64(len(cells))
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/census"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestCensus places known objects on a 64x64 board, including one across the edge, and checks they are counted.
+func TestCensus(t *testing.T) {
+	var alive []util.Cell
+	place := func(pattern string, x, y int) {
+		for row, line := range strings.Split(pattern, "/") {
+			for col, c := range line {
+				if c == 'O' {
+					alive = append(alive, util.Cell{X: (x + col) % 64, Y: (y + row) % 64})
+				}
+			}
+		}
+	}
+	place("OO/OO", 63, 10)                  // block wrapping around the left/right edge
+	place("OO/OO", 30, 30)                  // block
+	place("O/O/O", 5, 5)                    // vertical blinker
+	place("OO./O.O/O..", 20, 50)            // glider, rotated
+	place("O..O./....O/O...O/.OOOO", 40, 5) // LWSS, reflected
+	place("OO../O.../...O/..OO", 50, 40)    // beacon in the phase that is not 8-connected
+	place(".OO./O..O/.OO.", 10, 30)         // beehive
+	place("OOO/O../.O.", 25, 20)            // a glider, also rotated
+	place("OOOO", 5, 60)                    // not a stable object
+
+	counts := census.Count(alive, 64, 64)
+	expected := map[string]int{
+		"block":     2,
+		"blinker":   1,
+		"glider":    2,
+		"LWSS":      1,
+		"beacon":    1,
+		"beehive":   1,
+		"unknown 4": 1,
+	}
+	for name, count := range expected {
+		assert(t, counts[name] == count, "Counted %v %v, expected %v\n%v", counts[name], name, count, census.Report(counts))
+	}
+	assert(t, len(counts) == len(expected), "Counted unexpected objects\n%v", census.Report(counts))
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"net"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/broker"
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/stubs"
+	"uk.ac.bris.cs/gameoflife/util"
+	"uk.ac.bris.cs/gameoflife/worker"
+)
+
+// TestCluster runs 100 turns of the 16x16 image on a broker with three workers and checks the result.
+func TestCluster(t *testing.T) {
+	var addrs []string
+	for i := 0; i < 3; i++ {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		util.Check(err)
+		defer listener.Close()
+		go worker.Serve(listener)
+		addrs = append(addrs, listener.Addr().String())
+	}
+	b, err := broker.New(addrs)
+	util.Check(err)
+	defer b.Close()
+
+	world, err := gol.ReadPgm("images/16x16.pgm")
+	util.Check(err)
+	res := new(stubs.TurnsResponse)
+	err = b.Turns(stubs.TurnsRequest{World: world, Turns: 100}, res)
+	util.Check(err)
+	assert(t, res.CompletedTurns == 100, "Broker completed %v turns, expected 100\n", res.CompletedTurns)
+
+	var cells []util.Cell
+	for y := range res.World {
+		for x := range res.World[y] {
+			if res.World[y][x] != 0 {
+				cells = append(cells, util.Cell{X: x, Y: y})
+			}
+		}
+	}
+	expected := readAliveCells("check/images/16x16x100.pgm", 16, 16)
+	assertEqualBoard(t, cells, expected, gol.Params{ImageWidth: 16, ImageHeight: 16, Turns: 100})
+}
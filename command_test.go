@@ -0,0 +1,70 @@
+package main
+
+import (
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestCommands drives a 16x16 simulation with a gol.Controller, checking each Command's Ack
+// without waiting on timers.
+func TestCommands(t *testing.T) {
+	p := gol.Params{Turns: 100000000, Threads: 2, ImageWidth: 16, ImageHeight: 16}
+	alive := readAliveCounts(p.ImageWidth, p.ImageHeight)
+	events := make(chan gol.Event, 1000)
+	commands := make(chan gol.Command)
+	controller := gol.Controller(commands)
+	go gol.RunCommands(p, events, commands)
+
+	edits := make(chan gol.CellsFlipped, 10)
+	final := make(chan gol.FinalTurnComplete, 1)
+	go func() {
+		for event := range events {
+			switch e := event.(type) {
+			case gol.CellsFlipped:
+				if len(e.Cells) == 1 && e.Cells[0] == (util.Cell{X: 0, Y: 0}) {
+					edits <- e
+				}
+			case gol.FinalTurnComplete:
+				final <- e
+			}
+		}
+	}()
+
+	ack := controller.Do(gol.Pause)
+	assert(t, ack.Err == nil && ack.State == gol.Paused, "Pause acknowledged with %+v, expected no error and Paused\n", ack)
+	turn := ack.CompletedTurns
+
+	ack = controller.Send(gol.Command{Kind: gol.Step, Turns: 10})
+	assert(t, ack.Err == nil && ack.CompletedTurns == turn+10,
+		"Step acknowledged with %+v, expected no error at turn %v\n", ack, turn+10)
+	turn = ack.CompletedTurns
+
+	// Flipping the same cell twice leaves the world as it was.
+	for i := 0; i < 2; i++ {
+		ack = controller.Send(gol.Command{Kind: gol.EditCells, Cells: []util.Cell{{X: 16, Y: -16}}})
+		assert(t, ack.Err == nil && ack.CompletedTurns == turn, "EditCells acknowledged with %+v, expected no error at turn %v\n", ack, turn)
+		e := <-edits
+		assert(t, e.CompletedTurns == turn, "Edit sent for turn %v, expected %v\n", e.CompletedTurns, turn)
+	}
+
+	ack = controller.Do(gol.Resume)
+	assert(t, ack.Err == nil && ack.State == gol.Executing, "Resume acknowledged with %+v, expected no error and Executing\n", ack)
+	ack = controller.Send(gol.Command{Kind: gol.Step, Turns: 1})
+	assert(t, ack.Err != nil, "Step while executing acknowledged with %+v, expected an error\n", ack)
+
+	ack = controller.Do(gol.Pause)
+	turn = ack.CompletedTurns
+	ack = controller.Do(gol.Quit)
+	assert(t, ack.Err == nil && ack.State == gol.Quitting && ack.CompletedTurns == turn,
+		"Quit acknowledged with %+v, expected no error and Quitting at turn %v\n", ack, turn)
+
+	e := <-final
+	if turn <= 10000 {
+		assert(t, len(e.Alive) == alive[turn], "At turn %v expected %v alive cells, got %v instead\n", turn, alive[turn], len(e.Alive))
+	}
+
+	ack = controller.Do(gol.Pause)
+	assert(t, ack.Err != nil, "Pause after Quit acknowledged with %+v, expected an error\n", ack)
+}
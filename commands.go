@@ -0,0 +1,288 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"uk.ac.bris.cs/gameoflife/broker"
+	"uk.ac.bris.cs/gameoflife/config"
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/metrics"
+	"uk.ac.bris.cs/gameoflife/server"
+	"uk.ac.bris.cs/gameoflife/util"
+	"uk.ac.bris.cs/gameoflife/worker"
+)
+
+// subcommand is one of the tools in this binary, picked by the first argument.
+type subcommand struct {
+	name    string
+	summary string
+	run     func(args []string)
+}
+
+// subcommands is filled in by init, as the usage messages of the subcommands refer back to it.
+var subcommands []subcommand
+
+func init() {
+	subcommands = []subcommand{
+		{"run", "Run the simulation and show it in a viewer. This is the default.", runCommand},
+		{"serve", "Run the RPC server that computes whole runs for the distributor.", serveCommand},
+		{"worker", "Run an RPC worker that computes strips of each turn for a broker.", workerCommand},
+		{"broker", "Run an RPC broker that splits each turn between workers.", brokerCommand},
+		{"bench", "Measure turns per second across board sizes, thread counts and engines.", benchCommand},
+		{"verify", "Check the engine against the golden images in check/.", verifyCommand},
+		{"convert", "Convert a pattern between file formats.", convertCommand},
+		{"diff", "Show where two worlds differ, in the terminal and as an image.", diffCommand},
+		{"census", "Count the objects in a world after some turns, or search random soups.", censusCommand},
+	}
+}
+
+// usage lists the subcommands.
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: go run . [command] [flags]\n\nCommands:\n")
+	for _, command := range subcommands {
+		fmt.Fprintf(os.Stderr, "  %-8v %v\n", command.name, command.summary)
+	}
+	fmt.Fprintf(os.Stderr, "\nUse 'go run . <command> -help' to list the flags of a command.\n")
+}
+
+// loadConfig reads the config file named by a -config flag in args, or returns the defaults if there is none.
+func loadConfig(args []string) config.Config {
+	path := config.Path(args)
+	if path == "" {
+		return config.Default()
+	}
+	c, err := config.Load(path)
+	util.Check(err)
+	return c
+}
+
+// newFlagSet returns the flags for a subcommand, whose usage message includes its summary.
+// Every subcommand accepts -config, which has already been read by loadConfig to give the defaults of the other flags.
+func newFlagSet(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	flags.String(
+		"config",
+		"",
+		"Read defaults from this JSON config file. Flags given as well override values in the file.")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: go run . %v [flags]\n", name)
+		for _, command := range subcommands {
+			if command.name == name {
+				fmt.Fprintf(flags.Output(), "%v\n", command.summary)
+			}
+		}
+		fmt.Fprintf(flags.Output(), "\nFlags:\n")
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+// paramsFlags adds the flags describing the board and the run, shared by every subcommand that runs the engine.
+// The values already in params are used as the defaults.
+func paramsFlags(flags *flag.FlagSet, params *gol.Params) {
+	flags.IntVar(
+		&params.Threads,
+		"t",
+		params.Threads,
+		"Specify the number of server threads to use.")
+
+	flags.IntVar(
+		&params.ImageWidth,
+		"w",
+		params.ImageWidth,
+		"Specify the width of the image.")
+
+	flags.IntVar(
+		&params.ImageHeight,
+		"h",
+		params.ImageHeight,
+		"Specify the height of the image.")
+
+	flags.IntVar(
+		&params.Turns,
+		"turns",
+		params.Turns,
+		"Specify the number of turns to process.")
+
+	flags.StringVar(
+		&params.InputDir,
+		"images",
+		params.InputDir,
+		"Directory to read <w>x<h>.pgm from.")
+
+	flags.StringVar(
+		&params.OutputDir,
+		"out",
+		params.OutputDir,
+		"Directory to save images to.")
+}
+
+// listenFlags adds the flags shared by the RPC subcommands, with defaults from listen.
+func listenFlags(flags *flag.FlagSet, listen config.Listen) (port, metricsAddr *string) {
+	port = flags.String(
+		"port",
+		listen.Port,
+		"Port to listen on.")
+
+	metricsAddr = flags.String(
+		"metrics",
+		listen.Metrics,
+		"Serve Prometheus metrics at /metrics on this address (e.g. :9101).")
+	return port, metricsAddr
+}
+
+// viewerFlags adds -sdl, -headless, -term and -web, which all set viewer. Whichever is given last wins,
+// and viewer keeps the value from the config file if none are.
+func viewerFlags(flags *flag.FlagSet, viewer *string) {
+	flags.Var(
+		viewerFlag{viewer, "sdl"},
+		"sdl",
+		"Show the board in the SDL window. This is the default unless the config file picks another viewer.")
+
+	flags.Var(
+		viewerFlag{viewer, "headless"},
+		"headless",
+		"Disable the SDL window for running in a headless environment.")
+
+	flags.Var(
+		viewerFlag{viewer, "term"},
+		"term",
+		"Draw the board in the terminal instead of the SDL window, e.g. over SSH.")
+
+	flags.Var(
+		webFlag{viewer},
+		"web",
+		"Serve a browser viewer on this address (e.g. :8080) instead of the SDL window.")
+}
+
+// viewerFlag is a boolean flag that picks the viewer called name.
+type viewerFlag struct {
+	viewer *string
+	name   string
+}
+
+func (v viewerFlag) IsBoolFlag() bool {
+	return true
+}
+
+func (v viewerFlag) String() string {
+	return strconv.FormatBool(v.viewer != nil && *v.viewer == v.name)
+}
+
+func (v viewerFlag) Set(value string) error {
+	on, err := strconv.ParseBool(value)
+	if err != nil {
+		return err
+	}
+	if on {
+		*v.viewer = v.name
+	} else if *v.viewer == v.name {
+		*v.viewer = "sdl"
+	}
+	return nil
+}
+
+// webFlag picks the web viewer on the address it is given.
+type webFlag struct {
+	viewer *string
+}
+
+func (w webFlag) String() string {
+	if w.viewer == nil {
+		return ""
+	}
+	return webViewer(*w.viewer)
+}
+
+func (w webFlag) Set(value string) error {
+	if webViewer(value) == "" {
+		return fmt.Errorf("expected an address such as :8080, not %q", value)
+	}
+	*w.viewer = value
+	return nil
+}
+
+// webViewer returns the address to serve the web viewer on if the configured viewer is one, or "" otherwise.
+func webViewer(viewer string) string {
+	switch viewer {
+	case "sdl", "term", "headless":
+		return ""
+	}
+	return viewer
+}
+
+// listen starts serving metrics, if asked to, and opens the port for RPC calls.
+func listen(name, port, metricsAddr string) net.Listener {
+	if metricsAddr != "" {
+		metrics.Serve(metricsAddr)
+	}
+	listener, err := net.Listen("tcp", ":"+port)
+	util.Check(err)
+	fmt.Printf("%v listening on %v\n", name, listener.Addr())
+	return listener
+}
+
+func serveCommand(args []string) {
+	flags := newFlagSet("serve")
+	port, metricsAddr := listenFlags(flags, loadConfig(args).Server)
+	flags.Parse(args)
+
+	listener := listen("Server", *port, *metricsAddr)
+	defer listener.Close()
+	server.Serve(listener)
+}
+
+func workerCommand(args []string) {
+	flags := newFlagSet("worker")
+	port, metricsAddr := listenFlags(flags, loadConfig(args).Worker)
+	flags.Parse(args)
+
+	listener := listen("Worker", *port, *metricsAddr)
+	defer listener.Close()
+	worker.Serve(listener)
+}
+
+func brokerCommand(args []string) {
+	flags := newFlagSet("broker")
+	cfg := loadConfig(args)
+	port, metricsAddr := listenFlags(flags, cfg.Broker.Listen)
+	workers := flags.String(
+		"workers",
+		strings.Join(cfg.Broker.Workers, ","),
+		"Comma-separated addresses of the workers to split turns between.")
+	flags.Parse(args)
+
+	b, err := broker.New(strings.Split(*workers, ","))
+	util.Check(err)
+	defer b.Close()
+	listener := listen("Broker", *port, *metricsAddr)
+	defer listener.Close()
+	broker.Serve(listener, b)
+}
+
+// parseInts reads a comma-separated list of numbers, such as "16,64,512".
+func parseInts(s string) ([]int, error) {
+	var values []int
+	for _, field := range strings.Split(s, ",") {
+		value, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// joinInts writes a list of numbers in the form read by parseInts.
+func joinInts(values []int) string {
+	fields := make([]string, len(values))
+	for i, value := range values {
+		fields[i] = strconv.Itoa(value)
+	}
+	return strings.Join(fields, ",")
+}
@@ -0,0 +1,187 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+)
+
+// Config describes a run or a cluster, so that an experiment can be reproduced from one committed file.
+// Flags given on the command line override the values in the file.
+//
+// An example file:
+//
+//	{
+//	    "run": {"threads": 8, "width": 512, "height": 512, "turns": 1000, "breakpoints": ["population<100"]},
+//	    "rule": "B3/S23",
+//	    "input": "images",
+//	    "output": "out",
+//	    "broker": {"port": "8030", "workers": ["lab01:8040", "lab02:8040"]},
+//	    "worker": {"port": "8040"},
+//	    "sinks": {"log": "run.jsonl", "statsCsv": "stats.csv", "metrics": ":9100"}
+//	}
+type Config struct {
+	Run    Run    `json:"run"`
+	Rule   string `json:"rule"`   // birth/survival rule, e.g. B3/S23 or hex B2/S34
+	Input  string `json:"input"`  // directory that <width>x<height>.pgm is read from
+	Output string `json:"output"` // directory that images are saved to
+	Server Listen `json:"server"` // the RPC server started by 'serve'
+	Broker Broker `json:"broker"`
+	Worker Listen `json:"worker"`
+	Sinks  Sinks  `json:"sinks"`
+	Viewer string `json:"viewer"` // sdl, term, headless or an address for the web viewer such as :8080
+}
+
+// Run holds the fields of gol.Params in a form that is easy to write by hand.
+type Run struct {
+	Threads          int            `json:"threads"`
+	Width            int            `json:"width"`
+	Height           int            `json:"height"`
+	Turns            int            `json:"turns"`
+	StatsEvery       int            `json:"statsEvery"`
+	TrackEvery       int            `json:"trackEvery"`
+	SnapshotEvery    int            `json:"snapshotEvery"`
+	SnapshotInterval Duration       `json:"snapshotInterval"`
+	Breakpoints      []string       `json:"breakpoints"`
+	Engine           gol.EngineKind `json:"engine"` // auto, dense or sparse
+	Unbounded        bool           `json:"unbounded"`
+}
+
+// Listen is where an RPC subcommand listens.
+type Listen struct {
+	Port    string `json:"port"`
+	Metrics string `json:"metrics"` // address to serve Prometheus metrics on; empty disables them
+}
+
+// Broker is where the broker listens and the workers it splits turns between.
+type Broker struct {
+	Listen
+	Workers []string `json:"workers"`
+}
+
+// Sinks are the optional consumers of a run's events.
+type Sinks struct {
+	Log      string `json:"log"`      // JSON lines event log
+	StatsCSV string `json:"statsCsv"` // PopulationStats as CSV
+	Census   bool   `json:"census"`   // print a census of the final world
+	Metrics  string `json:"metrics"`  // address to serve Prometheus metrics on
+}
+
+// Duration is a time.Duration written as a string such as "30s".
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string such as \"30s\": %v", err)
+	}
+	duration, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(duration)
+	return nil
+}
+
+// Default returns the values used when neither a file nor a flag sets them.
+func Default() Config {
+	return Config{
+		Run:    Run{Threads: 8, Width: 512, Height: 512, Turns: 10000000000},
+		Rule:   gol.ConwayRule,
+		Input:  "images",
+		Output: "out",
+		Server: Listen{Port: "8030"},
+		Broker: Broker{Listen: Listen{Port: "8030"}, Workers: []string{"127.0.0.1:8040"}},
+		Worker: Listen{Port: "8040"},
+		Viewer: "sdl",
+	}
+}
+
+// Load reads a config file over the defaults. Unknown fields are an error, so that a typo
+// cannot silently leave a setting at its default.
+func Load(path string) (Config, error) {
+	config := Default()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return config, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&config); err != nil {
+		return config, fmt.Errorf("%v: %v", path, err)
+	}
+	if err := config.Check(); err != nil {
+		return config, fmt.Errorf("%v: %v", path, err)
+	}
+	return config, nil
+}
+
+// Check reports settings that cannot be used.
+func (config Config) Check() error {
+	_, err := config.Params()
+	return err
+}
+
+// Params converts the run settings to gol.Params.
+func (config Config) Params() (gol.Params, error) {
+	p := gol.Params{
+		Turns:            config.Run.Turns,
+		Threads:          config.Run.Threads,
+		ImageWidth:       config.Run.Width,
+		ImageHeight:      config.Run.Height,
+		StatsEvery:       config.Run.StatsEvery,
+		TrackEvery:       config.Run.TrackEvery,
+		SnapshotEvery:    config.Run.SnapshotEvery,
+		SnapshotInterval: time.Duration(config.Run.SnapshotInterval),
+		Engine:           config.Run.Engine,
+		Unbounded:        config.Run.Unbounded,
+		InputDir:         config.Input,
+		OutputDir:        config.Output,
+	}
+	for _, condition := range config.Run.Breakpoints {
+		breakpoint, err := gol.ParseBreakpoint(condition)
+		if err != nil {
+			return p, err
+		}
+		p.Breakpoints = append(p.Breakpoints, breakpoint)
+	}
+	rule, err := gol.ParseRule(config.Rule)
+	if err != nil {
+		return p, err
+	}
+	p.Rule = rule
+	if err := p.CheckRule(); err != nil {
+		return p, err
+	}
+	return p, p.CheckBreakpoints()
+}
+
+// Path returns the value of a -config flag in args, or "" if there is none. It is needed before
+// the other flags are parsed, as their defaults come from the file.
+func Path(args []string) string {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		name := strings.TrimLeft(arg, "-")
+		if len(name) == len(arg) {
+			continue
+		}
+		if name == "config" && i+1 < len(args) {
+			return args[i+1]
+		}
+		if strings.HasPrefix(name, "config=") {
+			return strings.TrimPrefix(name, "config=")
+		}
+	}
+	return ""
+}
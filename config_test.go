@@ -0,0 +1,71 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"uk.ac.bris.cs/gameoflife/config"
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestConfig loads a config file and checks that its values, and the defaults for everything else, reach gol.Params.
+func TestConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "run.json")
+	err := os.WriteFile(path, []byte(`{
+		"run": {"threads": 4, "width": 64, "height": 64, "turns": 100, "snapshotInterval": "30s", "breakpoints": ["turn=50"]},
+		"output": "results",
+		"broker": {"workers": ["lab01:8040", "lab02:8040"]}
+	}`), 0666)
+	util.Check(err)
+
+	assert(t, config.Path([]string{"-t", "2", "-config", path}) == path, "Path did not find -config %v\n", path)
+	assert(t, config.Path([]string{"--config=" + path}) == path, "Path did not find --config=%v\n", path)
+	assert(t, config.Path([]string{"-t", "2"}) == "", "Path found a config file where there is none\n")
+
+	c, err := config.Load(path)
+	util.Check(err)
+	p, err := c.Params()
+	util.Check(err)
+	assert(t, p.Threads == 4 && p.ImageWidth == 64 && p.ImageHeight == 64 && p.Turns == 100,
+		"Params are %+v, expected 4 threads, 64x64 and 100 turns\n", p)
+	assert(t, p.SnapshotInterval == 30*time.Second, "SnapshotInterval is %v, expected 30s\n", p.SnapshotInterval)
+	assert(t, len(p.Breakpoints) == 1 && p.Breakpoints[0] == gol.Breakpoint{Kind: gol.TurnReached, Turn: 50},
+		"Breakpoints are %v, expected turn=50\n", p.Breakpoints)
+	assert(t, p.InputPath() == filepath.Join("images", "64x64.pgm"), "InputPath is %v, expected the default images directory\n", p.InputPath())
+	assert(t, p.OutputDir == "results", "OutputDir is %v, expected results\n", p.OutputDir)
+	assert(t, len(c.Broker.Workers) == 2 && c.Broker.Port == "8030", "Broker is %+v, expected 2 workers on the default port\n", c.Broker)
+
+	err = os.WriteFile(path, []byte(`{"run": {"thread": 4}}`), 0666)
+	util.Check(err)
+	_, err = config.Load(path)
+	assert(t, err != nil, "Loaded a config with an unknown field without an error\n")
+}
+
+// TestViewerFlags checks that the last viewer flag wins over the others and over the viewer in the config file.
+func TestViewerFlags(t *testing.T) {
+	tests := []struct {
+		config   string
+		args     []string
+		expected string
+	}{
+		{"sdl", nil, "sdl"},
+		{":8080", nil, ":8080"},
+		{":8080", []string{"-term"}, "term"},
+		{"headless", []string{"-sdl"}, "sdl"},
+		{"term", []string{"-term=false"}, "sdl"},
+		{"sdl", []string{"-term", "-web", ":9000"}, ":9000"},
+		{"sdl", []string{"-web", ":9000", "-headless"}, "headless"},
+	}
+	for _, test := range tests {
+		flags := flag.NewFlagSet("run", flag.ContinueOnError)
+		viewer := test.config
+		viewerFlags(flags, &viewer)
+		util.Check(flags.Parse(test.args))
+		assert(t, viewer == test.expected, "Viewer %v with %v is %v, expected %v\n", test.config, test.args, viewer, test.expected)
+	}
+}
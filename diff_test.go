@@ -0,0 +1,53 @@
+package main
+
+import (
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestDiffWorlds compares two 512x512 worlds that differ in two far apart places.
+func TestDiffWorlds(t *testing.T) {
+	got := make([][]uint8, 512)
+	expected := make([][]uint8, 512)
+	for y := range got {
+		got[y] = make([]uint8, 512)
+		expected[y] = make([]uint8, 512)
+	}
+	got[10][10], expected[10][10] = 255, 255
+	got[10][11] = 255
+	expected[400][300] = 255
+
+	d := util.DiffWorlds(got, expected)
+	assert(t, len(d.Extra) == 1 && d.Extra[0] == util.Cell{X: 11, Y: 10}, "Extra cells are %v, expected (11, 10)\n", d.Extra)
+	assert(t, len(d.Missing) == 1 && d.Missing[0] == util.Cell{X: 300, Y: 400}, "Missing cells are %v, expected (300, 400)\n", d.Missing)
+
+	regions := d.Regions(2)
+	assert(t, len(regions) == 2, "Found %v regions, expected 2\n", len(regions))
+	assert(t, regions[0] == util.DiffRegion{MinX: 9, MinY: 8, MaxX: 13, MaxY: 12, Extra: 1},
+		"First region is %+v, expected (9, 8) to (13, 12) with 1 extra cell\n", regions[0])
+
+	render := d.Render(got, expected, 2, 10, false)
+	assert(t, strings.Count(render, "++") == 2 && strings.Count(render, "--") == 2 && strings.Count(render, "██") == 2,
+		"Render drew\n%v", render)
+
+	path := filepath.Join(t.TempDir(), "diff.png")
+	util.Check(d.WritePng(path, got, expected, 2))
+	f, err := os.Open(path)
+	util.Check(err)
+	defer f.Close()
+	img, err := png.Decode(f)
+	util.Check(err)
+	assert(t, img.Bounds().Dx() == 1024, "Image is %v pixels wide, expected 1024\n", img.Bounds().Dx())
+	assert(t, color.RGBAModel.Convert(img.At(601, 801)) == color.RGBA{G: 255, A: 255}, "Missing cell is not green\n")
+
+	small := util.DiffWorlds([][]uint8{{255}}, expected)
+	assert(t, small.Width == 512 && len(small.Extra) == 1 && len(small.Missing) == 2,
+		"Comparing a 1x1 world with a 512x512 one gave %vx%v with %v extra and %v missing\n",
+		small.Width, small.Height, len(small.Extra), len(small.Missing))
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"reflect"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestEventLog checks that events written by gol.LogEvents are replayed unchanged, after a header
+// describing the board.
+func TestEventLog(t *testing.T) {
+	sent := []gol.Event{
+		gol.CellFlipped{CompletedTurns: 0, Cell: util.Cell{X: 1, Y: 2}},
+		gol.StateChange{CompletedTurns: 0, NewState: gol.Executing},
+		gol.CellsFlipped{CompletedTurns: 1, Cells: []util.Cell{{X: 3, Y: 4}, {X: 5, Y: 6}}},
+		gol.TurnComplete{CompletedTurns: 1},
+		gol.AliveCellsCount{CompletedTurns: 1, CellsCount: 3},
+		gol.ImageOutputComplete{CompletedTurns: 1, Filename: "16x16x1"},
+		gol.FinalTurnComplete{CompletedTurns: 1, Alive: []util.Cell{{X: 1, Y: 2}, {X: 3, Y: 4}, {X: 5, Y: 6}}},
+		gol.StateChange{CompletedTurns: 1, NewState: gol.Quitting},
+	}
+
+	var log bytes.Buffer
+	events := make(chan gol.Event, len(sent))
+	logged := make(chan gol.Event, len(sent))
+	for _, event := range sent {
+		events <- event
+	}
+	close(events)
+	rule, err := gol.ParseRule("hex B2/S34")
+	util.Check(err)
+	p := gol.Params{ImageWidth: 16, ImageHeight: 8, Rule: rule}
+	err = gol.LogEvents(p, events, logged, &log)
+	util.Check(err)
+	for _, event := range sent {
+		passed := <-logged
+		assert(t, reflect.DeepEqual(passed, event), "LogEvents passed on %#v, expected %#v\n", passed, event)
+	}
+
+	r := bufio.NewReader(&log)
+	header, err := gol.ReadLogHeader(r)
+	util.Check(err)
+	replayParams, err := header.Params(gol.Params{Threads: 4})
+	util.Check(err)
+	assert(t, replayParams.ImageWidth == 16 && replayParams.ImageHeight == 8 && replayParams.Rule == rule,
+		"Log header gave a %vx%v board under %v, expected 16x8 under %v\n",
+		replayParams.ImageWidth, replayParams.ImageHeight, replayParams.Rule, rule)
+
+	replayed := make(chan gol.Event, len(sent))
+	err = gol.ReplayEvents(r, replayed, nil, 0)
+	util.Check(err)
+	i := 0
+	for event := range replayed {
+		if i >= len(sent) {
+			t.Fatalf("ERROR: Replayed more events than were logged, extra %#v", event)
+		}
+		assert(t, reflect.DeepEqual(event, sent[i]), "Replayed %#v, expected %#v\n", event, sent[i])
+		i++
+	}
+	assert(t, i == len(sent), "Replayed %v events, expected %v\n", i, len(sent))
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestFormats writes the 64x64 image in every pattern format and checks that it reads back the same.
+// Life 1.06 and Macrocell only record alive cells, so they read back as the cropped world.
+func TestFormats(t *testing.T) {
+	dir := t.TempDir()
+	world, err := gol.ReadPgm("images/64x64.pgm")
+	util.Check(err)
+	cropped, _, _ := gol.Crop(world)
+
+	for _, format := range gol.PatternFormats {
+		path := filepath.Join(dir, "64x64"+format)
+		util.Check(gol.WritePattern(path, world, gol.Conway))
+		read, err := gol.ReadPattern(path)
+		util.Check(err)
+		expected := world
+		if format == ".lif" || format == ".life" || format == ".mc" {
+			expected = cropped
+		}
+		assert(t, reflect.DeepEqual(read, expected), "%v did not read back the world it wrote\n", format)
+	}
+}
+
+// TestRle reads a glider written by hand and places it in the middle of a bigger board.
+func TestRle(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "glider.rle")
+	err := os.WriteFile(path, []byte("#N Glider\nx = 3, y = 3, rule = B3/S23\nbo$2bo$3o!\n"), 0666)
+	util.Check(err)
+	glider, err := gol.ReadPattern(path)
+	util.Check(err)
+	expected := [][]byte{{0, 255, 0}, {0, 0, 255}, {255, 255, 255}}
+	assert(t, reflect.DeepEqual(glider, expected), "Read glider as %v, expected %v\n", glider, expected)
+
+	board := gol.Place(glider, 16, 16, 6, 6)
+	cropped, x, y := gol.Crop(board)
+	assert(t, x == 6 && y == 6 && reflect.DeepEqual(cropped, glider), "Cropped glider at (%v, %v) as %v\n", x, y, cropped)
+
+	err = os.WriteFile(path, []byte("x = 3, y = 3, rule = B9/S23\nbo$2bo$3o!\n"), 0666)
+	util.Check(err)
+	_, err = gol.ReadPattern(path)
+	assert(t, err != nil, "Read a pattern with birth on 9 neighbours without an error\n")
+}
+
+// TestPatternRules checks that the rule is written to and read back from the formats that record one.
+func TestPatternRules(t *testing.T) {
+	dir := t.TempDir()
+	glider := [][]byte{{0, 255, 0}, {0, 0, 255}, {255, 255, 255}}
+	for _, notation := range []string{"B36/S23", "hex B2/S34"} {
+		rule, err := gol.ParseRule(notation)
+		util.Check(err)
+		for _, format := range []string{".pgm", ".rle", ".mc"} {
+			path := filepath.Join(dir, "glider"+format)
+			util.Check(gol.WritePattern(path, glider, rule))
+			_, read, err := gol.ReadPatternRule(path)
+			util.Check(err)
+			assert(t, read == rule, "%v read back rule %v as %v\n", format, rule, read)
+		}
+	}
+
+	path := filepath.Join(dir, "highlife.rle")
+	util.Check(os.WriteFile(path, []byte("x = 3, y = 3, rule = 23/36\nbo$2bo$3o!\n"), 0666))
+	_, rule, err := gol.ReadPatternRule(path)
+	util.Check(err)
+	assert(t, rule.String() == "B36/S23", "Read S/B rule 23/36 as %v\n", rule)
+}
+
+// TestBadPatterns checks that broken files are an error rather than a panic.
+func TestBadPatterns(t *testing.T) {
+	dir := t.TempDir()
+	for name, data := range map[string]string{
+		"short.pbm":     "P4 8 1",
+		"level0.mc":     "[M2] (gameoflife)\n0 0 0 0 0\n",
+		"negative.mc":   "[M2] (gameoflife)\n-2 0 0 0 0\n",
+		"unfinished.mc": "[M2] (gameoflife)\n4 0 0 0 0\n5 1 0 0 7\n",
+	} {
+		path := filepath.Join(dir, name)
+		util.Check(os.WriteFile(path, []byte(data), 0666))
+		_, err := gol.ReadPattern(path)
+		assert(t, err != nil, "Read %v without an error\n", name)
+	}
+}
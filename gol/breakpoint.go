@@ -0,0 +1,107 @@
+package gol
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// BreakpointKind says which condition a Breakpoint waits for.
+type BreakpointKind uint8
+
+const (
+	// TurnReached pauses once Breakpoint.Turn turns have completed.
+	TurnReached BreakpointKind = iota
+	// PopulationBelow pauses when the number of alive cells drops below Breakpoint.Population.
+	PopulationBelow
+	// CellAlive pauses when Breakpoint.Cell becomes alive.
+	CellAlive
+)
+
+// Breakpoint pauses the simulation, sending StateChange{Paused}, when its condition starts to hold.
+// Conditions are checked after every turn, and only trigger on the turn they become true, so resuming
+// does not pause again straight away.
+type Breakpoint struct {
+	Kind       BreakpointKind
+	Turn       int
+	Population int
+	Cell       util.Cell
+}
+
+// ParseBreakpoint reads a breakpoint written as "turn=N", "population<N" or "cell=X,Y".
+func ParseBreakpoint(s string) (Breakpoint, error) {
+	switch {
+	case strings.HasPrefix(s, "turn="):
+		turn, err := strconv.Atoi(strings.TrimPrefix(s, "turn="))
+		if err != nil {
+			return Breakpoint{}, fmt.Errorf("breakpoint %q: %v", s, err)
+		}
+		return Breakpoint{Kind: TurnReached, Turn: turn}, nil
+	case strings.HasPrefix(s, "population<"):
+		population, err := strconv.Atoi(strings.TrimPrefix(s, "population<"))
+		if err != nil {
+			return Breakpoint{}, fmt.Errorf("breakpoint %q: %v", s, err)
+		}
+		return Breakpoint{Kind: PopulationBelow, Population: population}, nil
+	case strings.HasPrefix(s, "cell="):
+		coords := strings.Split(strings.TrimPrefix(s, "cell="), ",")
+		if len(coords) != 2 {
+			return Breakpoint{}, fmt.Errorf("breakpoint %q: expected cell=X,Y", s)
+		}
+		x, err := strconv.Atoi(coords[0])
+		if err != nil {
+			return Breakpoint{}, fmt.Errorf("breakpoint %q: %v", s, err)
+		}
+		y, err := strconv.Atoi(coords[1])
+		if err != nil {
+			return Breakpoint{}, fmt.Errorf("breakpoint %q: %v", s, err)
+		}
+		return Breakpoint{Kind: CellAlive, Cell: util.Cell{X: x, Y: y}}, nil
+	}
+	return Breakpoint{}, fmt.Errorf("breakpoint %q: expected turn=N, population<N or cell=X,Y", s)
+}
+
+// CheckBreakpoints reports cell breakpoints outside the world p describes, which could never be hit.
+// An unbounded world has no edges, so any cell can be.
+func (p Params) CheckBreakpoints() error {
+	for _, b := range p.Breakpoints {
+		if b.Kind == CellAlive && !p.Unbounded &&
+			(b.Cell.X < 0 || b.Cell.Y < 0 || b.Cell.X >= p.ImageWidth || b.Cell.Y >= p.ImageHeight) {
+			return fmt.Errorf("breakpoint %v is outside the %vx%v world", b, p.ImageWidth, p.ImageHeight)
+		}
+	}
+	return nil
+}
+
+func (b Breakpoint) String() string {
+	switch b.Kind {
+	case TurnReached:
+		return fmt.Sprintf("turn=%v", b.Turn)
+	case PopulationBelow:
+		return fmt.Sprintf("population<%v", b.Population)
+	case CellAlive:
+		return fmt.Sprintf("cell=%v,%v", b.Cell.X, b.Cell.Y)
+	default:
+		return "Incorrect Breakpoint"
+	}
+}
+
+// hit reports whether the breakpoint's condition started to hold on the turn that just completed.
+// previousAlive is the population before the turn and isAlive looks up cells of the board after it.
+func (b Breakpoint) hit(turn, previousAlive, alive int, flipped []util.Cell, isAlive func(util.Cell) bool) bool {
+	switch b.Kind {
+	case TurnReached:
+		return turn == b.Turn
+	case PopulationBelow:
+		return alive < b.Population && previousAlive >= b.Population
+	case CellAlive:
+		for _, cell := range flipped {
+			if cell == b.Cell && isAlive(cell) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,194 @@
+package gol
+
+import (
+	"reflect"
+	"sync"
+)
+
+// Policy decides what a Subscription does when its consumer falls behind and its buffer is full.
+type Policy int
+
+const (
+	// Block makes the publisher wait for the consumer, so no events are lost.
+	Block Policy = iota
+	// DropOldest discards the oldest queued event to make room for the new one.
+	DropOldest
+	// Coalesce merges the new event into a queued event that it supersedes, such as an older
+	// AliveCellsCount, and merges cell flips from consecutive turns into a single net change.
+	// Events that cannot be merged wait as they would with Block.
+	Coalesce
+)
+
+// Bus fans events out to any number of subscribers, each with its own filter and buffering policy,
+// so that a viewer, a logger and a metrics exporter can all listen to one gol.Run.
+type Bus struct {
+	mutex       sync.Mutex
+	subscribers map[*Subscription]bool
+	closed      bool
+}
+
+// Subscription receives the events published on a Bus that pass its filter.
+type Subscription struct {
+	bus     *Bus
+	policy  Policy
+	size    int
+	types   map[reflect.Type]bool
+	out     chan Event
+	mutex   sync.Mutex
+	cond    *sync.Cond
+	queue   []Event
+	closed  bool
+	dropped int
+}
+
+func NewBus() *Bus {
+	return &Bus{subscribers: make(map[*Subscription]bool)}
+}
+
+// Subscribe registers a new subscriber that can queue up to size events.
+// Only events of the same types as the given examples are delivered, e.g.
+// bus.Subscribe(DropOldest, 1, AliveCellsCount{}); with no examples every event is delivered.
+func (b *Bus) Subscribe(policy Policy, size int, filter ...Event) *Subscription {
+	if size < 1 {
+		size = 1
+	}
+	s := &Subscription{
+		bus:    b,
+		policy: policy,
+		size:   size,
+		out:    make(chan Event),
+	}
+	s.cond = sync.NewCond(&s.mutex)
+	if len(filter) > 0 {
+		s.types = make(map[reflect.Type]bool)
+		for _, event := range filter {
+			s.types[reflect.TypeOf(event)] = true
+		}
+	}
+	go s.pump()
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.closed {
+		s.close()
+	} else {
+		b.subscribers[s] = true
+	}
+	return s
+}
+
+// Run publishes every event from events, then closes all subscriptions once events is closed.
+func (b *Bus) Run(events <-chan Event) {
+	for event := range events {
+		b.Publish(event)
+	}
+	b.Close()
+}
+
+// Publish delivers event to every interested subscriber.
+// It only blocks if a subscriber using the Block or Coalesce policy is full.
+func (b *Bus) Publish(event Event) {
+	b.mutex.Lock()
+	subscribers := make([]*Subscription, 0, len(b.subscribers))
+	for s := range b.subscribers {
+		subscribers = append(subscribers, s)
+	}
+	b.mutex.Unlock()
+	for _, s := range subscribers {
+		s.publish(event)
+	}
+}
+
+// Close closes every subscription. Subscribers still receive any events already queued.
+func (b *Bus) Close() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.closed = true
+	for s := range b.subscribers {
+		s.close()
+		delete(b.subscribers, s)
+	}
+}
+
+// Events returns the channel the subscriber reads from. It is closed when the subscription ends.
+func (s *Subscription) Events() <-chan Event {
+	return s.out
+}
+
+// Dropped returns how many events have been discarded by the DropOldest policy.
+func (s *Subscription) Dropped() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.dropped
+}
+
+// Unsubscribe stops delivery to this subscriber and closes its channel once the queue is drained.
+func (s *Subscription) Unsubscribe() {
+	s.bus.mutex.Lock()
+	delete(s.bus.subscribers, s)
+	s.bus.mutex.Unlock()
+	s.close()
+}
+
+func (s *Subscription) close() {
+	s.mutex.Lock()
+	s.closed = true
+	s.cond.Broadcast()
+	s.mutex.Unlock()
+}
+
+func (s *Subscription) publish(event Event) {
+	if s.types != nil && !s.types[reflect.TypeOf(event)] {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.closed {
+		return
+	}
+	switch s.policy {
+	case DropOldest:
+		if len(s.queue) >= s.size {
+			s.queue = s.queue[1:]
+			s.dropped++
+		}
+	case Coalesce:
+		if len(s.queue) >= s.size && s.coalesce(event) {
+			s.cond.Broadcast()
+			return
+		}
+		fallthrough
+	case Block:
+		for len(s.queue) >= s.size && !s.closed {
+			s.cond.Wait()
+		}
+		if s.closed {
+			return
+		}
+	}
+	s.queue = append(s.queue, event)
+	s.cond.Broadcast()
+}
+
+// pump hands queued events to the subscriber one at a time.
+func (s *Subscription) pump() {
+	for {
+		s.mutex.Lock()
+		for len(s.queue) == 0 && !s.closed {
+			s.cond.Wait()
+		}
+		if len(s.queue) == 0 {
+			s.mutex.Unlock()
+			close(s.out)
+			return
+		}
+		event := s.queue[0]
+		s.queue = s.queue[1:]
+		if delta, ok := event.(*flipDelta); ok {
+			event = delta.event()
+		}
+		s.cond.Broadcast()
+		s.mutex.Unlock()
+		s.out <- event
+	}
+}
@@ -0,0 +1,119 @@
+package gol
+
+import (
+	"fmt"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// flipDelta is the net change from several queued CellFlipped and CellsFlipped events.
+// A cell flipped an even number of times is not in the set. It only ever lives in a
+// Subscription's queue and is handed to the subscriber as a CellsFlipped.
+type flipDelta struct {
+	turn  int
+	cells map[util.Cell]bool
+}
+
+func (delta *flipDelta) String() string {
+	return fmt.Sprintf("%v cells flipped", len(delta.cells))
+}
+
+func (delta *flipDelta) GetCompletedTurns() int {
+	return delta.turn
+}
+
+func (delta *flipDelta) flip(turn int, cells []util.Cell) {
+	delta.turn = turn
+	for _, cell := range cells {
+		if delta.cells[cell] {
+			delete(delta.cells, cell)
+		} else {
+			delta.cells[cell] = true
+		}
+	}
+}
+
+func (delta *flipDelta) event() CellsFlipped {
+	cells := make([]util.Cell, 0, len(delta.cells))
+	for cell := range delta.cells {
+		cells = append(cells, cell)
+	}
+	return CellsFlipped{CompletedTurns: delta.turn, Cells: cells}
+}
+
+// flippedCells returns the cells changed by a flip event, or false if event is not one.
+func flippedCells(event Event) ([]util.Cell, bool) {
+	switch e := event.(type) {
+	case CellFlipped:
+		return []util.Cell{e.Cell}, true
+	case CellsFlipped:
+		return e.Cells, true
+	}
+	return nil, false
+}
+
+// asDelta converts a queued flip event into a flipDelta that further flips can be merged into.
+func asDelta(event Event) (*flipDelta, bool) {
+	if delta, ok := event.(*flipDelta); ok {
+		return delta, true
+	}
+	cells, ok := flippedCells(event)
+	if !ok {
+		return nil, false
+	}
+	delta := &flipDelta{cells: make(map[util.Cell]bool)}
+	delta.flip(event.GetCompletedTurns(), cells)
+	return delta, true
+}
+
+// coalesce tries to merge event into a full queue, returning false if it has to wait for room.
+//
+// A newer AliveCellsCount replaces any queued one. Cell flips are merged into the flips at the end
+// of the queue, dropping the TurnComplete between them: the subscriber skips that frame, but every
+// TurnComplete it does receive still follows exactly the flips that bring it up to that turn.
+// A TurnComplete is always accepted after flips, even beyond the queue size, so the publisher
+// never waits while a later turn could still be merged.
+func (s *Subscription) coalesce(event Event) bool {
+	last := len(s.queue) - 1
+	switch event.(type) {
+	case AliveCellsCount:
+		for i, queued := range s.queue {
+			if _, ok := queued.(AliveCellsCount); ok {
+				s.queue = append(s.queue[:i], s.queue[i+1:]...)
+				s.queue = append(s.queue, event)
+				return true
+			}
+		}
+	case TurnComplete:
+		if _, ok := s.queue[last].(TurnComplete); ok {
+			s.queue[last] = event
+			return true
+		}
+		if _, ok := asDelta(s.queue[last]); ok {
+			s.queue = append(s.queue, event)
+			return true
+		}
+	case CellFlipped, CellsFlipped:
+		cells, _ := flippedCells(event)
+		droppedTurn := false
+		if _, ok := s.queue[last].(TurnComplete); ok {
+			s.queue = s.queue[:last]
+			last--
+			droppedTurn = true
+		}
+		if last >= 0 {
+			if delta, ok := asDelta(s.queue[last]); ok {
+				delta.flip(event.GetCompletedTurns(), cells)
+				s.queue[last] = delta
+				return true
+			}
+		}
+		if droppedTurn {
+			delta := &flipDelta{cells: make(map[util.Cell]bool)}
+			delta.flip(event.GetCompletedTurns(), cells)
+			s.queue = append(s.queue, delta)
+			return true
+		}
+	}
+	return false
+}
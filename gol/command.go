@@ -0,0 +1,130 @@
+package gol
+
+import (
+	"fmt"
+	"time"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// CommandKind says what a Command asks the distributor to do.
+type CommandKind uint8
+
+const (
+	// Pause stops turns being processed. Pausing while already paused does nothing.
+	Pause CommandKind = iota
+	// Resume starts processing turns again after a Pause.
+	Resume
+	// TogglePause pauses a running simulation or resumes a paused one, like the p key.
+	TogglePause
+	// Save writes the current world to a PGM image in out/.
+	Save
+	// Quit saves the world and stops, like reaching the last turn.
+	Quit
+	// Shutdown stops like Quit. In the distributed version it also stops the server.
+	Shutdown
+	// Step processes Command.Turns turns while paused.
+	Step
+	// SetSpeed waits at least Command.Delay between turns; a zero Delay runs as fast as possible.
+	SetSpeed
+	// EditCells flips every cell in Command.Cells.
+	EditCells
+	// LoadPattern makes every cell in Command.Cells alive, offset by Command.Origin.
+	LoadPattern
+)
+
+// Command is a request to control a running simulation.
+// If Reply is not nil, the distributor sends exactly one Ack on it once the command has been carried out,
+// so Reply should be buffered if the sender does not wait for it.
+type Command struct {
+	Kind   CommandKind
+	Turns  int
+	Delay  time.Duration
+	Cells  []util.Cell
+	Origin util.Cell
+	Reply  chan<- Ack
+}
+
+// Ack reports the result of a Command and the state of the simulation just after it.
+type Ack struct {
+	CompletedTurns int
+	State          State
+	Err            error
+}
+
+// Controller sends Commands to a simulation started with RunCommands and waits for each to be acknowledged.
+type Controller chan<- Command
+
+// Send sends command and blocks until it has been carried out.
+func (c Controller) Send(command Command) Ack {
+	reply := make(chan Ack, 1)
+	command.Reply = reply
+	c <- command
+	return <-reply
+}
+
+// Do sends a Command that needs no arguments, such as Pause or Quit.
+func (c Controller) Do(kind CommandKind) Ack {
+	return c.Send(Command{Kind: kind})
+}
+
+// KeyCommands turns the key presses understood by the distributor into Commands:
+// p toggles pause, s saves, q quits and k shuts down. Other keys are ignored.
+// commands is closed once keyPresses is closed.
+func KeyCommands(keyPresses <-chan rune, commands chan<- Command) {
+	defer close(commands)
+	for key := range keyPresses {
+		switch key {
+		case 'p':
+			commands <- Command{Kind: TogglePause}
+		case 's':
+			commands <- Command{Kind: Save}
+		case 'q':
+			commands <- Command{Kind: Quit}
+		case 'k':
+			commands <- Command{Kind: Shutdown}
+		}
+	}
+}
+
+func (kind CommandKind) String() string {
+	switch kind {
+	case Pause:
+		return "Pause"
+	case Resume:
+		return "Resume"
+	case TogglePause:
+		return "TogglePause"
+	case Save:
+		return "Save"
+	case Quit:
+		return "Quit"
+	case Shutdown:
+		return "Shutdown"
+	case Step:
+		return "Step"
+	case SetSpeed:
+		return "SetSpeed"
+	case EditCells:
+		return "EditCells"
+	case LoadPattern:
+		return "LoadPattern"
+	default:
+		return "Incorrect CommandKind"
+	}
+}
+
+// reply acknowledges command, if its sender asked for an Ack.
+func reply(command Command, turn int, state State, err error) {
+	if command.Reply != nil {
+		command.Reply <- Ack{CompletedTurns: turn, State: state, Err: err}
+	}
+}
+
+// rejectCommands acknowledges every later Command with an error once the simulation has finished,
+// so that a Controller never blocks on a simulation that has stopped.
+func rejectCommands(commands <-chan Command, turn int) {
+	for command := range commands {
+		reply(command, turn, Quitting, fmt.Errorf("%v: the simulation has finished", command.Kind))
+	}
+}
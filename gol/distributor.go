@@ -2,10 +2,9 @@ package gol
 
 // acts as the client
 import (
-	"flag"
 	"fmt"
-	"net/rpc"
-	"uk.ac.bris.cs/gameoflife/stubs"
+	"time"
+	"uk.ac.bris.cs/gameoflife/util"
 )
 
 type DistributorChannels struct {
@@ -15,6 +14,8 @@ type DistributorChannels struct {
 	ioFilename chan<- string
 	ioOutput   chan<- uint8
 	IoInput    <-chan uint8
+	ioRegion   chan<- ioRegion
+	commands   <-chan Command
 }
 
 // distributor constructs a filename based on parameters
@@ -31,15 +32,13 @@ func distributor(p Params, c DistributorChannels) {
 	H := p.ImageHeight
 	W := p.ImageWidth
 
-	turn := 0
 	world := make([][]uint8, H) // create a slice with 16 rows
 	for i := 0; i < H; i++ {
 		world[i] = make([]uint8, W) // initialise each row with 16 columns
 	}
 	c.ioCommand <- ioInput
 
-	filename := fmt.Sprintf("%dx%d", p.ImageHeight, p.ImageWidth)
-	c.ioFilename <- filename
+	c.ioFilename <- p.inputName()
 
 	// fill in the 2d slice
 	for y := 0; y < H; y++ {
@@ -50,16 +49,33 @@ func distributor(p Params, c DistributorChannels) {
 	c.ioCommand <- ioCheckIdle
 	<-c.ioIdle
 
-	c.events <- StateChange{turn, Executing}
+	util.Check(p.CheckRule())
+	util.Check(p.CheckBreakpoints())
+	initial := calculateAliveCells(world)
+	l := &turnLoop{p: p, c: c, world: world, alive: len(initial), state: Executing}
+	if p.Unbounded {
+		l.universe = newUniverse(world, p.rule())
+		l.world = nil
+	}
+	if len(initial) > 0 {
+		c.events <- CellsFlipped{CompletedTurns: 0, Cells: initial}
+	}
+	c.events <- StateChange{l.turn, Executing}
 
 	// Executing each turn should be in the server
 	// Client sends the world as an RPC call to the server
 	// Server sends back the world after the turns have been done
-	// TODO: Execute all turns of the Game of Life.
+	l.run()
 
-	// TODO: Report the final state using FinalTurnCompleteEvent.
-	alives := calculateAliveCells(world)
-	c.events <- FinalTurnComplete{CompletedTurns: p.Turns, Alive: alives}
+	// Report the final state using FinalTurnCompleteEvent.
+	l.save()
+	var alives []util.Cell
+	if l.universe != nil {
+		alives = l.universe.cells()
+	} else {
+		alives = calculateAliveCells(l.world)
+	}
+	c.events <- FinalTurnComplete{CompletedTurns: l.turn, Alive: alives}
 	// send an event down an events channel
 	// must implement the events channel, FinalTurnComplete is an event so must implement the event interface
 	// Make sure that the Io has finished any output before exiting.
@@ -69,21 +85,227 @@ func distributor(p Params, c DistributorChannels) {
 	c.ioCommand <- ioCheckIdle
 	<-c.ioIdle
 
-	c.events <- StateChange{turn, Quitting}
+	c.events <- StateChange{l.turn, Quitting}
+	if l.quit != nil {
+		reply(*l.quit, l.turn, Quitting, nil)
+	}
+	if l.c.commands != nil {
+		go rejectCommands(l.c.commands, l.turn)
+	}
 
 	// Close the channel to stop the SDL goroutine gracefully. Removing may cause deadlock.
 	close(c.events)
 }
 
-func main() {
-	// connect to RPC server and send a request
-	server := flag.String("server", "127.0.0.1:8030", "IP:port string to connect to as server")
-	flag.Parse()
-	fmt.Println("Server: ", *server)
-	client, _ := rpc.Dial("tcp", *server)
-	defer client.Close()
-	request := stubs.Request{World: world, P: p, C: c}
-	response := new(stubs.Response)
-	client.Call(stubs.ReverseHandler, request, response)
-	fmt.Println("Responded: ")
+// turnLoop is the state the distributor keeps while processing turns and Commands.
+type turnLoop struct {
+	p        Params
+	c        DistributorChannels
+	world    [][]uint8
+	turn     int
+	alive    int
+	state    State
+	delay    time.Duration
+	quit     *Command     // the Quit or Shutdown command that stopped the loop, acknowledged once the world is saved
+	sparse   *sparseWorld // the alive cells, kept while the sparse engine is in use
+	tiles    *TiledWorld  // the tiles that changed, kept while the dense engine is in use
+	universe *universe    // the alive cells of an unbounded world, which has no l.world
+}
+
+// run processes turns until the last one or a Quit, reporting the number of alive cells every two seconds
+// and saving snapshots every p.SnapshotInterval, if it is set.
+func (l *turnLoop) run() {
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+	var snapshots <-chan time.Time
+	if l.p.SnapshotInterval > 0 {
+		snapshotTicker := time.NewTicker(l.p.SnapshotInterval)
+		defer snapshotTicker.Stop()
+		snapshots = snapshotTicker.C
+	}
+	for l.turn < l.p.Turns && l.quit == nil {
+		if l.state == Paused {
+			if l.c.commands == nil {
+				// Nothing can resume the simulation, so finish it.
+				return
+			}
+			command, ok := <-l.c.commands
+			l.accept(command, ok)
+			continue
+		}
+		select {
+		case command, ok := <-l.c.commands:
+			l.accept(command, ok)
+			continue
+		case <-ticker.C:
+			l.c.events <- AliveCellsCount{l.turn, l.alive}
+		case <-snapshots:
+			l.save()
+		default:
+		}
+		l.advance()
+		if l.delay > 0 {
+			select {
+			case command, ok := <-l.c.commands:
+				l.accept(command, ok)
+			case <-time.After(l.delay):
+			}
+		}
+	}
+}
+
+// accept handles a Command received from the commands channel, which has been closed if ok is false.
+func (l *turnLoop) accept(command Command, ok bool) {
+	if !ok {
+		l.c.commands = nil
+		return
+	}
+	l.handle(command)
+}
+
+// advance computes the next turn and sends the cells that changed.
+// It then saves a snapshot every p.SnapshotEvery turns and pauses if a breakpoint was hit.
+func (l *turnLoop) advance() {
+	flipped := l.nextTurn()
+	previousAlive := l.alive
+	for _, cell := range flipped {
+		if l.isAlive(cell) {
+			l.alive++
+		} else {
+			l.alive--
+		}
+	}
+	l.turn++
+	if len(flipped) > 0 {
+		l.c.events <- CellsFlipped{CompletedTurns: l.turn, Cells: flipped}
+	}
+	l.c.events <- TurnComplete{CompletedTurns: l.turn}
+
+	// The final turn is always saved, so it does not need a snapshot as well.
+	if l.p.SnapshotEvery > 0 && l.turn%l.p.SnapshotEvery == 0 && l.turn < l.p.Turns {
+		l.save()
+	}
+	for _, breakpoint := range l.p.Breakpoints {
+		if l.state != Paused && breakpoint.hit(l.turn, previousAlive, l.alive, flipped, l.isAlive) {
+			l.c.events <- BreakpointHit{CompletedTurns: l.turn, Breakpoint: breakpoint}
+			l.setState(Paused)
+		}
+	}
+}
+
+// handle carries out a Command and acknowledges it, apart from Quit and Shutdown which are
+// acknowledged once the distributor has finished.
+func (l *turnLoop) handle(command Command) {
+	var err error
+	switch command.Kind {
+	case Pause, Resume, TogglePause:
+		paused := command.Kind == Pause || (command.Kind == TogglePause && l.state == Executing)
+		if paused && l.state != Paused {
+			l.setState(Paused)
+		} else if !paused && l.state != Executing {
+			l.setState(Executing)
+		}
+	case Save:
+		l.save()
+	case Quit, Shutdown:
+		// In the distributed version Shutdown also stops the server; locally there is nothing else to stop.
+		l.quit = &command
+		return
+	case Step:
+		if l.state != Paused {
+			err = fmt.Errorf("step: the simulation must be paused")
+		} else if command.Turns < 1 {
+			err = fmt.Errorf("step: %v turns requested", command.Turns)
+		}
+		for i := 0; err == nil && i < command.Turns && l.turn < l.p.Turns; i++ {
+			l.advance()
+		}
+	case SetSpeed:
+		if command.Delay < 0 {
+			err = fmt.Errorf("set speed: negative delay %v", command.Delay)
+		} else {
+			l.delay = command.Delay
+		}
+	case EditCells:
+		l.edit(command.Cells, util.Cell{}, true)
+	case LoadPattern:
+		l.edit(command.Cells, command.Origin, false)
+	default:
+		err = fmt.Errorf("unknown command %v", command.Kind)
+	}
+	reply(command, l.turn, l.state, err)
+}
+
+func (l *turnLoop) setState(state State) {
+	l.state = state
+	l.c.events <- StateChange{l.turn, state}
+}
+
+// isAlive reports whether a cell of the current turn is alive.
+func (l *turnLoop) isAlive(cell util.Cell) bool {
+	if l.universe != nil {
+		return l.universe.alive[cell]
+	}
+	return l.world[cell.Y][cell.X] != 0
+}
+
+// edit flips cells, or only makes them alive if flip is false, after moving them by origin.
+// Cells outside the board wrap around, as they would when computing a turn, unless it is unbounded.
+func (l *turnLoop) edit(cells []util.Cell, origin util.Cell, flip bool) {
+	var flipped []util.Cell
+	for _, cell := range cells {
+		cell = util.Cell{X: cell.X + origin.X, Y: cell.Y + origin.Y}
+		if l.universe == nil {
+			cell.X = (cell.X%l.p.ImageWidth + l.p.ImageWidth) % l.p.ImageWidth
+			cell.Y = (cell.Y%l.p.ImageHeight + l.p.ImageHeight) % l.p.ImageHeight
+		}
+		if !flip && l.isAlive(cell) {
+			continue
+		}
+		if l.universe != nil {
+			l.universe.flip(cell)
+		} else {
+			l.world[cell.Y][cell.X] = ^l.world[cell.Y][cell.X]
+		}
+		if l.isAlive(cell) {
+			l.alive++
+		} else {
+			l.alive--
+		}
+		flipped = append(flipped, cell)
+		if l.sparse != nil {
+			l.sparse.flip(cell)
+		}
+		if l.tiles != nil {
+			l.tiles.Changed(cell)
+		}
+	}
+	if len(flipped) > 0 {
+		l.c.events <- CellsFlipped{CompletedTurns: l.turn, Cells: flipped}
+	}
+}
+
+// save sends the world to the io goroutine to be written as out/<width>x<height>x<turn>.pgm.
+// An unbounded world is cropped to its alive cells, and the file records where the crop starts.
+func (l *turnLoop) save() {
+	world, width, height := l.world, l.p.ImageWidth, l.p.ImageHeight
+	if l.universe != nil {
+		var offset util.Cell
+		world, offset = l.universe.crop()
+		width, height = len(world[0]), len(world)
+		l.c.ioCommand <- ioOutputRegion
+		l.c.ioRegion <- ioRegion{X: offset.X, Y: offset.Y, Width: width, Height: height}
+	} else {
+		l.c.ioCommand <- ioOutput
+	}
+	filename := fmt.Sprintf("%dx%dx%d", width, height, l.turn)
+	l.c.ioFilename <- filename
+	for y := range world {
+		for x := range world[y] {
+			l.c.ioOutput <- world[y][x]
+		}
+	}
+	l.c.ioCommand <- ioCheckIdle
+	<-l.c.ioIdle
+	l.c.events <- ImageOutputComplete{l.turn, filename}
 }
@@ -0,0 +1,104 @@
+package gol
+
+import (
+	"sync"
+	"time"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// NextWorld computes the next turn of a world that wraps around at its edges.
+// The rows are split into p.Threads horizontal strips that are computed in parallel.
+func NextWorld(world [][]byte, p Params) [][]byte {
+	height := len(world)
+	width := 0
+	if height > 0 {
+		width = len(world[0])
+	}
+	next := make([][]byte, height)
+	for i := range next {
+		next[i] = make([]byte, width)
+	}
+
+	threads := p.Threads
+	if threads < 1 {
+		threads = 1
+	}
+	if threads > height {
+		threads = height
+	}
+	var wg sync.WaitGroup
+	for worker := 0; worker < threads; worker++ {
+		startY := worker * height / threads
+		endY := (worker + 1) * height / threads
+		wg.Add(1)
+		go func(worker, startY, endY int) {
+			defer wg.Done()
+			start := time.Now()
+			nextStrip(world, next, startY, endY)
+			ObserveStep(worker, time.Since(start))
+		}(worker, startY, endY)
+	}
+	wg.Wait()
+	return next
+}
+
+// NextRows computes rows startY to endY-1 of the turn after world, for a worker that only
+// handles part of the board. The whole world is needed for the rows either side.
+func NextRows(world [][]byte, startY, endY int) [][]byte {
+	next := make([][]byte, len(world))
+	for y := startY; y < endY; y++ {
+		next[y] = make([]byte, len(world[y]))
+	}
+	nextStrip(world, next, startY, endY)
+	return next[startY:endY]
+}
+
+// nextStrip writes rows startY to endY-1 of the next turn into next.
+func nextStrip(world, next [][]byte, startY, endY int) {
+	height := len(world)
+	width := len(world[0])
+	for y := startY; y < endY; y++ {
+		up := world[(y-1+height)%height]
+		row := world[y]
+		down := world[(y+1)%height]
+		for x := 0; x < width; x++ {
+			left, right := x-1, x+1
+			if left < 0 {
+				left = width - 1
+			}
+			if right == width {
+				right = 0
+			}
+			sum := alive(up[left]) + alive(up[x]) + alive(up[right]) +
+				alive(row[left]) + alive(row[right]) +
+				alive(down[left]) + alive(down[x]) + alive(down[right])
+			if sum == 3 || (sum == 2 && row[x] != 0) {
+				next[y][x] = 255
+			} else {
+				next[y][x] = 0
+			}
+		}
+	}
+}
+
+// alive returns 1 for a live cell and 0 for a dead one.
+func alive(cell byte) int {
+	if cell != 0 {
+		return 1
+	}
+	return 0
+}
+
+// calculateAliveCells returns the coordinates of every alive cell in the world.
+func calculateAliveCells(world [][]byte) []util.Cell {
+	alives := make([]util.Cell, 0)
+	for y, row := range world {
+		for x, cell := range row {
+			if cell != 0 {
+				alives = append(alives, util.Cell{X: x, Y: y})
+			}
+		}
+	}
+	return alives
+}
@@ -2,6 +2,8 @@ package gol
 
 import (
 	"fmt"
+	"math"
+
 	"uk.ac.bris.cs/gameoflife/util"
 )
 
@@ -77,6 +79,42 @@ type FinalTurnComplete struct {
 	Alive          []util.Cell
 }
 
+// `PopulationStats` is an Event reporting statistics about the live cells after a turn.
+// This Event is sent every Params.StatsEvery turns, if StatsEvery is positive.
+// Births and Deaths count the cells that changed in the most recent turn.
+// The bounding box is inclusive and is all -1 if there are no live cells.
+// Density[i][j] is the fraction of cells alive in region row i, column j of a StatsRegions x StatsRegions grid.
+type PopulationStats struct {
+	CompletedTurns int
+	Alive          int
+	Births         int
+	Deaths         int
+	MinX, MinY     int
+	MaxX, MaxY     int
+	CentreX        float64
+	CentreY        float64
+	Density        [][]float64
+}
+
+// `SpaceshipSeen` is an Event reporting a glider, LWSS, MWSS or HWSS found on the board.
+// This Event is sent for every spaceship every Params.TrackEvery turns, if TrackEvery is positive.
+// ID stays the same while the spaceship keeps moving as expected, so successive events trace its trajectory.
+// X and Y are the centre of its cells and VX and VY its velocity in cells per turn, with Y increasing downwards.
+type SpaceshipSeen struct {
+	CompletedTurns int
+	ID             int
+	Name           string
+	X, Y           float64
+	VX, VY         float64
+}
+
+// `BreakpointHit` is an Event reporting the breakpoint that paused the run.
+// It is sent just before the StateChange to Paused that it causes.
+type BreakpointHit struct {
+	CompletedTurns int
+	Breakpoint     Breakpoint
+}
+
 // String methods allow the different types of Events and States to be printed.
 
 func (state State) String() string {
@@ -92,6 +130,21 @@ func (state State) String() string {
 	}
 }
 
+// MarshalText and UnmarshalText let a State be written by name, e.g. in event logs.
+func (state State) MarshalText() ([]byte, error) {
+	return []byte(state.String()), nil
+}
+
+func (state *State) UnmarshalText(text []byte) error {
+	for s := Paused; s <= Quitting; s++ {
+		if s.String() == string(text) {
+			*state = s
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown state %q", text)
+}
+
 func (event StateChange) String() string {
 	return fmt.Sprintf("%v", event.NewState)
 }
@@ -116,6 +169,57 @@ func (event ImageOutputComplete) GetCompletedTurns() int {
 	return event.CompletedTurns
 }
 
+func (event PopulationStats) String() string {
+	return fmt.Sprintf("Alive %v Births %v Deaths %v Box (%v,%v)-(%v,%v) Centre (%.1f,%.1f)",
+		event.Alive, event.Births, event.Deaths, event.MinX, event.MinY, event.MaxX, event.MaxY, event.CentreX, event.CentreY)
+}
+
+func (event PopulationStats) GetCompletedTurns() int {
+	return event.CompletedTurns
+}
+
+func (event BreakpointHit) String() string {
+	return fmt.Sprintf("Breakpoint %v", event.Breakpoint)
+}
+
+func (event BreakpointHit) GetCompletedTurns() int {
+	return event.CompletedTurns
+}
+
+// Direction returns the compass direction the spaceship is heading in, e.g. "NE", with north at the top of the board.
+func (event SpaceshipSeen) Direction() string {
+	direction := ""
+	if event.VY < 0 {
+		direction += "N"
+	} else if event.VY > 0 {
+		direction += "S"
+	}
+	if event.VX > 0 {
+		direction += "E"
+	} else if event.VX < 0 {
+		direction += "W"
+	}
+	return direction
+}
+
+// Speed returns the speed of the spaceship in terms of c, one cell per turn, e.g. "c/4" for a glider.
+func (event SpaceshipSeen) Speed() string {
+	speed := math.Max(math.Abs(event.VX), math.Abs(event.VY))
+	if speed == 0 {
+		return "0"
+	}
+	return fmt.Sprintf("c/%v", math.Round(1/speed))
+}
+
+func (event SpaceshipSeen) String() string {
+	return fmt.Sprintf("%v #%v at (%.1f,%.1f) heading %v at %v",
+		event.Name, event.ID, event.X, event.Y, event.Direction(), event.Speed())
+}
+
+func (event SpaceshipSeen) GetCompletedTurns() int {
+	return event.CompletedTurns
+}
+
 func (event CellFlipped) String() string {
 	return ""
 }
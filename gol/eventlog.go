@@ -0,0 +1,225 @@
+package gol
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"time"
+)
+
+// LogHeader is the first line of an event log, describing the board its events happened on, so that
+// a replay can be shown the same way.
+type LogHeader struct {
+	Width     int    `json:"width"`
+	Height    int    `json:"height"`
+	Rule      string `json:"rule"`
+	Unbounded bool   `json:"unbounded,omitempty"`
+}
+
+// Params returns p with the board described by the header.
+func (h LogHeader) Params(p Params) (Params, error) {
+	rule, err := ParseRule(h.Rule)
+	if err != nil {
+		return p, err
+	}
+	if h.Width <= 0 || h.Height <= 0 {
+		return p, fmt.Errorf("bad board size %vx%v in log header", h.Width, h.Height)
+	}
+	p.ImageWidth, p.ImageHeight, p.Rule, p.Unbounded = h.Width, h.Height, rule, h.Unbounded
+	return p, nil
+}
+
+// ReadLogHeader reads the header from the start of a log written by LogEvents.
+// Pass r on to ReplayEvents to replay the events that follow it.
+func ReadLogHeader(r *bufio.Reader) (LogHeader, error) {
+	var header LogHeader
+	line, err := r.ReadBytes('\n')
+	if err != nil && len(line) == 0 {
+		return header, fmt.Errorf("no log header: %v", err)
+	}
+	if err := json.Unmarshal(line, &header); err != nil || header.Rule == "" {
+		return header, fmt.Errorf("no log header, the log may be from an older version")
+	}
+	return header, nil
+}
+
+// eventRecord is one line of a JSON-lines event log.
+// Type is the name of the Event's Go type, Elapsed is the time in nanoseconds since the first
+// event was logged, and Event holds the Event's own fields, including CompletedTurns.
+type eventRecord struct {
+	Type    string          `json:"type"`
+	Elapsed time.Duration   `json:"elapsed"`
+	Event   json.RawMessage `json:"event"`
+}
+
+// eventTypes maps the type tag in a log back to the Event it was written from.
+var eventTypes = map[string]reflect.Type{}
+
+func registerEvents(events ...Event) {
+	for _, event := range events {
+		t := reflect.TypeOf(event)
+		eventTypes[t.Name()] = t
+	}
+}
+
+func init() {
+	registerEvents(
+		AliveCellsCount{},
+		ImageOutputComplete{},
+		StateChange{},
+		CellFlipped{},
+		CellsFlipped{},
+		TurnComplete{},
+		FinalTurnComplete{},
+		PopulationStats{},
+		SpaceshipSeen{},
+		BreakpointHit{},
+	)
+}
+
+// MarshalEvent encodes an Event as a single line of JSON, without the trailing newline.
+func MarshalEvent(event Event, elapsed time.Duration) ([]byte, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(eventRecord{
+		Type:    reflect.TypeOf(event).Name(),
+		Elapsed: elapsed,
+		Event:   payload,
+	})
+}
+
+// UnmarshalEvent decodes a line written by MarshalEvent.
+func UnmarshalEvent(line []byte) (Event, time.Duration, error) {
+	var record eventRecord
+	if err := json.Unmarshal(line, &record); err != nil {
+		return nil, 0, err
+	}
+	t, ok := eventTypes[record.Type]
+	if !ok {
+		return nil, 0, fmt.Errorf("unknown event type %q", record.Type)
+	}
+	event := reflect.New(t)
+	if err := json.Unmarshal(record.Event, event.Interface()); err != nil {
+		return nil, 0, err
+	}
+	return event.Elem().Interface().(Event), record.Elapsed, nil
+}
+
+// LogEvents writes a LogHeader for the board of p, then every event from events, to w as JSON lines,
+// and passes each event on to out. out is closed once events is closed, so LogEvents can sit between gol.Run
+// and a viewer. If out is nil, for example when reading from a Bus subscription, events are only logged.
+func LogEvents(p Params, events <-chan Event, out chan<- Event, w io.Writer) error {
+	if out != nil {
+		defer close(out)
+	}
+	writer := bufio.NewWriter(w)
+	start := time.Now()
+	header, logErr := json.Marshal(LogHeader{p.ImageWidth, p.ImageHeight, p.rule().String(), p.Unbounded})
+	if logErr == nil {
+		_, logErr = writer.Write(append(header, '\n'))
+	}
+	for event := range events {
+		if logErr == nil {
+			line, err := MarshalEvent(event, time.Since(start))
+			if err == nil {
+				_, err = writer.Write(append(line, '\n'))
+			}
+			if err == nil {
+				_, isTurn := event.(TurnComplete)
+				_, isFlip := event.(CellFlipped)
+				_, isFlips := event.(CellsFlipped)
+				if !isTurn && !isFlip && !isFlips {
+					err = writer.Flush()
+				}
+			}
+			logErr = err
+		}
+		if out != nil {
+			out <- event
+		}
+	}
+	if err := writer.Flush(); logErr == nil {
+		logErr = err
+	}
+	return logErr
+}
+
+// ReplayEvents reads the events of a log written by LogEvents, after its header has been read by
+// ReadLogHeader, and sends them, then closes events.
+// Speed scales the original timing: 1 replays in real time, 10 ten times faster and 0 as fast as possible.
+// Keys from keyPresses are handled like the distributor would: p pauses and resumes, q and k stop.
+func ReplayEvents(r io.Reader, events chan<- Event, keyPresses <-chan rune, speed float64) error {
+	defer close(events)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1<<30)
+	replay := replayState{events: events, keyPresses: keyPresses, start: time.Now()}
+
+	for scanner.Scan() {
+		event, elapsed, err := UnmarshalEvent(scanner.Bytes())
+		if err != nil {
+			return err
+		}
+		var due time.Duration
+		if speed > 0 {
+			due = time.Duration(float64(elapsed) / speed)
+		}
+		if !replay.waitUntil(due) {
+			events <- StateChange{replay.turn, Quitting}
+			return nil
+		}
+		replay.turn = event.GetCompletedTurns()
+		events <- event
+	}
+	return scanner.Err()
+}
+
+type replayState struct {
+	events     chan<- Event
+	keyPresses <-chan rune
+	start      time.Time
+	paused     time.Duration
+	turn       int
+}
+
+// waitUntil blocks until due has passed since the replay started, not counting time spent paused,
+// while handling keypresses. It returns false if the replay should stop.
+func (r *replayState) waitUntil(due time.Duration) bool {
+	for {
+		wait := time.NewTimer(due + r.paused - time.Since(r.start))
+		select {
+		case <-wait.C:
+			return true
+		case key := <-r.keyPresses:
+			wait.Stop()
+			switch key {
+			case 'p':
+				pauseStart := time.Now()
+				r.events <- StateChange{r.turn, Paused}
+				if !r.awaitResume() {
+					return false
+				}
+				r.events <- StateChange{r.turn, Executing}
+				r.paused += time.Since(pauseStart)
+			case 'q', 'k':
+				return false
+			}
+		}
+	}
+}
+
+// awaitResume blocks until p is pressed again, returning false if q or k is pressed instead.
+func (r *replayState) awaitResume() bool {
+	for key := range r.keyPresses {
+		switch key {
+		case 'p':
+			return true
+		case 'q', 'k':
+			return false
+		}
+	}
+	return false
+}
@@ -0,0 +1,634 @@
+package gol
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// PatternFormats lists the file extensions understood by ReadPattern and WritePattern.
+var PatternFormats = []string{".pgm", ".pbm", ".png", ".rle", ".cells", ".lif", ".life", ".mc"}
+
+// ReadPattern reads a world in the format given by the file extension.
+// Formats that only record alive cells, Life 1.06 and Macrocell, are read as the bounding box of those cells.
+// Greyscale images are returned as they are; use Threshold to decide which cells are alive.
+func ReadPattern(path string) ([][]byte, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".pgm", ".rle", ".mc":
+		world, _, err := ReadPatternRule(path)
+		return world, err
+	case ".pbm":
+		return ReadPbm(path)
+	case ".png":
+		return ReadPng(path)
+	case ".cells":
+		return ReadPlaintext(path)
+	case ".lif", ".life":
+		return ReadLife106(path)
+	}
+	return nil, fmt.Errorf("%v: unknown format, expected one of %v", path, strings.Join(PatternFormats, " "))
+}
+
+// ReadPatternRule is ReadPattern also returning the rule the file was written for. Only PGM, RLE and
+// Macrocell files record one, and the others, or files that leave it out, are read as Conway.
+func ReadPatternRule(path string) ([][]byte, Rule, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".pgm":
+		world, _, rule, err := readPgm(path)
+		if rule == nil {
+			return world, Conway, err
+		}
+		return world, *rule, err
+	case ".rle":
+		return ReadRle(path)
+	case ".mc":
+		return ReadMacrocell(path)
+	}
+	world, err := ReadPattern(path)
+	return world, Conway, err
+}
+
+// WritePattern writes a world in the format given by the file extension, recording rule in the formats
+// that have a place for it.
+func WritePattern(path string, world [][]byte, rule Rule) error {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".pgm":
+		return writePgm(path, world, ruleComment(rule))
+	case ".pbm":
+		return WritePbm(path, world)
+	case ".png":
+		return WritePng(path, world)
+	case ".rle":
+		return WriteRle(path, world, rule)
+	case ".cells":
+		return WritePlaintext(path, world)
+	case ".lif", ".life":
+		return WriteLife106(path, world)
+	case ".mc":
+		return WriteMacrocell(path, world, rule)
+	}
+	return fmt.Errorf("%v: unknown format, expected one of %v", path, strings.Join(PatternFormats, " "))
+}
+
+// newWorld returns a world of dead cells.
+func newWorld(width, height int) [][]byte {
+	world := make([][]byte, height)
+	for y := range world {
+		world[y] = make([]byte, width)
+	}
+	return world
+}
+
+// worldSize returns the width and height of a world.
+func worldSize(world [][]byte) (int, int) {
+	if len(world) == 0 {
+		return 0, 0
+	}
+	return len(world[0]), len(world)
+}
+
+// cellsWorld returns the bounding box of cells as a world.
+func cellsWorld(cells []util.Cell) ([][]byte, error) {
+	if len(cells) == 0 {
+		return [][]byte{}, nil
+	}
+	minX, minY, maxX, maxY := cells[0].X, cells[0].Y, cells[0].X, cells[0].Y
+	for _, cell := range cells {
+		minX, maxX = minInt(minX, cell.X), maxInt(maxX, cell.X)
+		minY, maxY = minInt(minY, cell.Y), maxInt(maxY, cell.Y)
+	}
+	width, height := maxX-minX+1, maxY-minY+1
+	if width > 1<<16 || height > 1<<16 {
+		return nil, fmt.Errorf("pattern is %vx%v, too big to hold as a world", width, height)
+	}
+	world := newWorld(width, height)
+	for _, cell := range cells {
+		world[cell.Y-minY][cell.X-minX] = 255
+	}
+	return world, nil
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// Threshold returns a copy of world in which cells at least threshold bright are alive and the rest dead.
+func Threshold(world [][]byte, threshold byte) [][]byte {
+	width, height := worldSize(world)
+	result := newWorld(width, height)
+	for y := range world {
+		for x, cell := range world[y] {
+			if cell >= threshold {
+				result[y][x] = 255
+			}
+		}
+	}
+	return result
+}
+
+// Crop returns the smallest part of world that holds all its alive cells, and the position of its
+// top-left corner in world. An empty world crops to nothing.
+func Crop(world [][]byte) ([][]byte, int, int) {
+	width, height := worldSize(world)
+	minX, minY, maxX, maxY := width, height, -1, -1
+	for y := range world {
+		for x, cell := range world[y] {
+			if cell != 0 {
+				minX, maxX = minInt(minX, x), maxInt(maxX, x)
+				minY, maxY = minInt(minY, y), maxInt(maxY, y)
+			}
+		}
+	}
+	if maxX < 0 {
+		return [][]byte{}, 0, 0
+	}
+	cropped := make([][]byte, maxY-minY+1)
+	for y := range cropped {
+		cropped[y] = append([]byte(nil), world[minY+y][minX:maxX+1]...)
+	}
+	return cropped, minX, minY
+}
+
+// Place copies world onto a dead width x height board with its top-left corner at (x, y).
+// Cells that fall outside the board are lost.
+func Place(world [][]byte, width, height, x, y int) [][]byte {
+	board := newWorld(width, height)
+	for wy := range world {
+		for wx, cell := range world[wy] {
+			if bx, by := x+wx, y+wy; bx >= 0 && bx < width && by >= 0 && by < height {
+				board[by][bx] = cell
+			}
+		}
+	}
+	return board
+}
+
+// ReadPbm reads a plain (P1) or binary (P4) PBM image, in which 1 is a black, alive, cell.
+func ReadPbm(path string) ([][]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	fields, pos := readHeader(data, 3)
+	if len(fields) < 3 || (fields[0] != "P1" && fields[0] != "P4") {
+		return nil, fmt.Errorf("%v: not a pbm file", path)
+	}
+	width, errW := strconv.Atoi(fields[1])
+	height, errH := strconv.Atoi(fields[2])
+	if errW != nil || errH != nil || width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("%v: bad size %vx%v", path, fields[1], fields[2])
+	}
+	world := newWorld(width, height)
+	if fields[0] == "P4" {
+		stride := (width + 7) / 8
+		if pos >= len(data) {
+			return nil, fmt.Errorf("%v: no pixels after the header", path)
+		}
+		bits := data[pos+1:]
+		if len(bits) < stride*height {
+			return nil, fmt.Errorf("%v: expected %v bytes of pixels, found %v", path, stride*height, len(bits))
+		}
+		for y := range world {
+			for x := range world[y] {
+				if bits[y*stride+x/8]&(0x80>>(x%8)) != 0 {
+					world[y][x] = 255
+				}
+			}
+		}
+		return world, nil
+	}
+	i := 0
+	for _, c := range data[pos:] {
+		if c != '0' && c != '1' {
+			continue
+		}
+		if i == width*height {
+			break
+		}
+		if c == '1' {
+			world[i/width][i%width] = 255
+		}
+		i++
+	}
+	if i < width*height {
+		return nil, fmt.Errorf("%v: expected %v pixels, found %v", path, width*height, i)
+	}
+	return world, nil
+}
+
+// WritePbm writes world as a binary (P4) PBM image.
+func WritePbm(path string, world [][]byte) error {
+	width, height := worldSize(world)
+	data := []byte(fmt.Sprintf("P4\n%v %v\n", width, height))
+	stride := (width + 7) / 8
+	for _, row := range world {
+		bits := make([]byte, stride)
+		for x, cell := range row {
+			if cell != 0 {
+				bits[x/8] |= 0x80 >> (x % 8)
+			}
+		}
+		data = append(data, bits...)
+	}
+	return os.WriteFile(path, data, 0666)
+}
+
+// ReadPng reads an image, converting every pixel to its grey level.
+func ReadPng(path string) ([][]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	img, err := png.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", path, err)
+	}
+	bounds := img.Bounds()
+	world := newWorld(bounds.Dx(), bounds.Dy())
+	for y := range world {
+		for x := range world[y] {
+			world[y][x] = color.GrayModel.Convert(img.At(bounds.Min.X+x, bounds.Min.Y+y)).(color.Gray).Y
+		}
+	}
+	return world, nil
+}
+
+// WritePng writes world as a greyscale image, with alive cells white as in the PGM images.
+func WritePng(path string, world [][]byte) error {
+	width, height := worldSize(world)
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for y, row := range world {
+		copy(img.Pix[y*img.Stride:], row)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = png.Encode(file, img)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// readRule reads the rule recorded in an RLE or Macrocell file, in the notation of ParseRule or in the
+// older S/B notation, such as 23/3. Files that leave it out are Conway.
+func readRule(s string) (Rule, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Conway, nil
+	}
+	digits := func(s string) bool {
+		return strings.Trim(s, "0123456789") == ""
+	}
+	if parts := strings.Split(s, "/"); len(parts) == 2 && digits(parts[0]) && digits(parts[1]) {
+		s = "B" + parts[1] + "/S" + parts[0]
+	}
+	return ParseRule(s)
+}
+
+// ReadRle reads a pattern in run length encoded format, and the rule in its header.
+// Any state other than b or . is alive.
+func ReadRle(path string) ([][]byte, Rule, error) {
+	rule := Conway
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, rule, err
+	}
+	var world [][]byte
+	var body strings.Builder
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if world == nil && strings.HasPrefix(line, "x") {
+			width, height := 0, 0
+			for _, field := range strings.Split(line, ",") {
+				pair := strings.SplitN(field, "=", 2)
+				if len(pair) != 2 {
+					return nil, rule, fmt.Errorf("%v: bad header field %q", path, field)
+				}
+				value := strings.TrimSpace(pair[1])
+				switch strings.TrimSpace(pair[0]) {
+				case "x":
+					width, err = strconv.Atoi(value)
+				case "y":
+					height, err = strconv.Atoi(value)
+				case "rule":
+					rule, err = readRule(value)
+				}
+				if err != nil {
+					return nil, rule, fmt.Errorf("%v: %v", path, err)
+				}
+			}
+			if width <= 0 || height <= 0 {
+				return nil, rule, fmt.Errorf("%v: bad size %vx%v", path, width, height)
+			}
+			world = newWorld(width, height)
+			continue
+		}
+		body.WriteString(line)
+	}
+	if world == nil {
+		return nil, rule, fmt.Errorf("%v: no x = ..., y = ... header", path)
+	}
+
+	x, y, count := 0, 0, 0
+	for _, c := range body.String() {
+		if c >= '0' && c <= '9' {
+			count = count*10 + int(c-'0')
+			continue
+		}
+		if count == 0 {
+			count = 1
+		}
+		switch c {
+		case '!':
+			return world, rule, nil
+		case '$':
+			x, y = 0, y+count
+		case 'b', '.':
+			x += count
+		default:
+			if y >= len(world) || x+count > len(world[y]) {
+				return nil, rule, fmt.Errorf("%v: cells outside the %vx%v board", path, len(world[0]), len(world))
+			}
+			for i := 0; i < count; i++ {
+				world[y][x+i] = 255
+			}
+			x += count
+		}
+		count = 0
+	}
+	return world, rule, nil
+}
+
+// WriteRle writes world in run length encoded format, with lines of at most 70 characters.
+// The header gives rule as ParseRule writes it, so grids other than square are named before it.
+func WriteRle(path string, world [][]byte, rule Rule) error {
+	width, height := worldSize(world)
+	var tokens []string
+	token := func(count int, tag byte) {
+		if count == 1 {
+			tokens = append(tokens, string(tag))
+		} else {
+			tokens = append(tokens, fmt.Sprintf("%v%c", count, tag))
+		}
+	}
+	newlines := 0
+	for _, row := range world {
+		end := len(row)
+		for end > 0 && row[end-1] == 0 {
+			end--
+		}
+		if end == 0 {
+			newlines++
+			continue
+		}
+		if newlines > 0 {
+			token(newlines, '$')
+		}
+		for x := 0; x < end; {
+			run := 1
+			for x+run < end && (row[x+run] != 0) == (row[x] != 0) {
+				run++
+			}
+			if row[x] != 0 {
+				token(run, 'o')
+			} else {
+				token(run, 'b')
+			}
+			x += run
+		}
+		newlines = 1
+	}
+	tokens = append(tokens, "!")
+
+	var output strings.Builder
+	output.WriteString(fmt.Sprintf("x = %v, y = %v, rule = %v\n", width, height, rule))
+	line := 0
+	for _, t := range tokens {
+		if line+len(t) > 70 {
+			output.WriteString("\n")
+			line = 0
+		}
+		output.WriteString(t)
+		line += len(t)
+	}
+	output.WriteString("\n")
+	return os.WriteFile(path, []byte(output.String()), 0666)
+}
+
+// ReadLife106 reads a pattern in Life 1.06 format: one "x y" line per alive cell.
+func ReadLife106(path string) ([][]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var cells []util.Cell
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		var cell util.Cell
+		if _, err := fmt.Sscan(text, &cell.X, &cell.Y); err != nil {
+			return nil, fmt.Errorf("%v: line %v: %v", path, line, err)
+		}
+		cells = append(cells, cell)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	world, err := cellsWorld(cells)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", path, err)
+	}
+	return world, nil
+}
+
+// WriteLife106 writes the alive cells of world in Life 1.06 format.
+func WriteLife106(path string, world [][]byte) error {
+	var output strings.Builder
+	output.WriteString("#Life 1.06\n")
+	for y := range world {
+		for x, cell := range world[y] {
+			if cell != 0 {
+				output.WriteString(fmt.Sprintf("%v %v\n", x, y))
+			}
+		}
+	}
+	return os.WriteFile(path, []byte(output.String()), 0666)
+}
+
+// macrocellNode is a line of a Macrocell file: an 8x8 leaf, or a square of 2^level cells made of four
+// nodes of the level below. Children are indexes of earlier lines, counting from 1, with 0 for empty.
+type macrocellNode struct {
+	level    int
+	cells    []util.Cell
+	children [4]int
+}
+
+// ReadMacrocell reads a pattern in Golly's Macrocell format, and the rule on its #R line.
+func ReadMacrocell(path string) ([][]byte, Rule, error) {
+	rule := Conway
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, rule, err
+	}
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r", ""), "\n")
+	if !strings.HasPrefix(lines[0], "[M2]") {
+		return nil, rule, fmt.Errorf("%v: not a macrocell file", path)
+	}
+	nodes := []macrocellNode{{}}
+	for i, line := range lines[1:] {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "#R"):
+			rule, err = readRule(strings.TrimPrefix(line, "#R"))
+			if err != nil {
+				return nil, rule, fmt.Errorf("%v: %v", path, err)
+			}
+		case strings.HasPrefix(line, "#"):
+		case strings.ContainsAny(line[:1], ".*$"):
+			node := macrocellNode{level: 3}
+			x, y := 0, 0
+			for _, c := range line {
+				switch c {
+				case '$':
+					x, y = 0, y+1
+				case '*':
+					node.cells = append(node.cells, util.Cell{X: x, Y: y})
+					x++
+				default:
+					x++
+				}
+			}
+			nodes = append(nodes, node)
+		default:
+			var node macrocellNode
+			_, err := fmt.Sscan(line, &node.level, &node.children[0], &node.children[1], &node.children[2], &node.children[3])
+			if err != nil {
+				return nil, rule, fmt.Errorf("%v: line %v: %v", path, i+2, err)
+			}
+			for _, child := range node.children {
+				if child >= len(nodes) || (child > 0 && nodes[child].level != node.level-1) {
+					return nil, rule, fmt.Errorf("%v: line %v: bad child %v", path, i+2, child)
+				}
+			}
+			if node.level < 4 {
+				return nil, rule, fmt.Errorf("%v: line %v: level %v is too small, as leaves are level 3", path, i+2, node.level)
+			}
+			if node.level > 30 {
+				return nil, rule, fmt.Errorf("%v: line %v: level %v is too big", path, i+2, node.level)
+			}
+			nodes = append(nodes, node)
+		}
+	}
+
+	// The last node is the root, which has its top-left corner at (0, 0).
+	var cells []util.Cell
+	var collect func(index, x, y int)
+	collect = func(index, x, y int) {
+		node := nodes[index]
+		if index == 0 {
+			return
+		}
+		if node.level == 3 {
+			for _, cell := range node.cells {
+				cells = append(cells, util.Cell{X: x + cell.X, Y: y + cell.Y})
+			}
+			return
+		}
+		half := 1 << (node.level - 1)
+		collect(node.children[0], x, y)
+		collect(node.children[1], x+half, y)
+		collect(node.children[2], x, y+half)
+		collect(node.children[3], x+half, y+half)
+	}
+	collect(len(nodes)-1, 0, 0)
+	world, err := cellsWorld(cells)
+	if err != nil {
+		return nil, rule, fmt.Errorf("%v: %v", path, err)
+	}
+	return world, rule, nil
+}
+
+// WriteMacrocell writes world in Golly's Macrocell format. Identical parts of the world are written once,
+// so big worlds of repeated or empty regions stay small. The #R line gives rule as ParseRule writes it.
+func WriteMacrocell(path string, world [][]byte, rule Rule) error {
+	width, height := worldSize(world)
+	level := 3
+	for 1<<level < width || 1<<level < height {
+		level++
+	}
+	var lines []string
+	index := make(map[string]int)
+	add := func(line string) int {
+		if i, ok := index[line]; ok {
+			return i
+		}
+		lines = append(lines, line)
+		index[line] = len(lines)
+		return len(lines)
+	}
+	alive := func(x, y int) bool {
+		return x < width && y < height && world[y][x] != 0
+	}
+	var node func(level, x, y int) int
+	node = func(level, x, y int) int {
+		if level == 3 {
+			var rows []string
+			empty := true
+			for dy := 0; dy < 8; dy++ {
+				row := []byte("........")
+				for dx := 0; dx < 8; dx++ {
+					if alive(x+dx, y+dy) {
+						row[dx] = '*'
+						empty = false
+					}
+				}
+				rows = append(rows, strings.TrimRight(string(row), "."))
+			}
+			if empty {
+				return 0
+			}
+			return add(strings.TrimRight(strings.Join(rows, "$")+"$", "$") + "$")
+		}
+		half := 1 << (level - 1)
+		nw, ne := node(level-1, x, y), node(level-1, x+half, y)
+		sw, se := node(level-1, x, y+half), node(level-1, x+half, y+half)
+		if nw == 0 && ne == 0 && sw == 0 && se == 0 {
+			return 0
+		}
+		return add(fmt.Sprintf("%v %v %v %v %v", level, nw, ne, sw, se))
+	}
+	if node(level, 0, 0) == 0 {
+		add("$")
+	}
+	output := "[M2] (gameoflife)\n#R " + rule.String() + "\n" + strings.Join(lines, "\n") + "\n"
+	return os.WriteFile(path, []byte(output), 0666)
+}
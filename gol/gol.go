@@ -1,15 +1,87 @@
 package gol
 
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+)
+
 // Params provides the details of how to run the Game of Life and which image to load.
 type Params struct {
 	Turns       int
 	Threads     int
 	ImageWidth  int
 	ImageHeight int
+	StatsEvery  int // how often, in turns, to send PopulationStats; 0 disables them
+	TrackEvery  int // how often, in turns, to look for spaceships and send SpaceshipSeen; 0 disables them
+
+	SnapshotEvery    int           // how often, in turns, to save the world to OutputDir; 0 disables it
+	SnapshotInterval time.Duration // how often, in time, to save the world to OutputDir; 0 disables it
+	Breakpoints      []Breakpoint  // conditions that pause the run
+	Engine           EngineKind    // how turns are computed; the zero value picks by density
+
+	// Rule decides which cells live on the next turn, and on which grid; the zero value is Conway. Engine is
+	// only used for Conway, and other rules are computed row by row.
+	Rule Rule
+
+	// Unbounded lets the world grow as patterns expand instead of wrapping at its edges. The image is read as
+	// usual and placed with its top-left cell at (0, 0), after which cells may have any coordinates. Engine is
+	// not used, snapshots are cropped to the alive cells, and stats and spaceship tracking are not supported.
+	Unbounded bool
+
+	InputDir  string // directory the initial image is read from; empty means images
+	OutputDir string // directory images are saved to; empty means out
+}
+
+// InputPath is the image a run with these Params starts from.
+func (p Params) InputPath() string {
+	return filepath.Join(p.inputDir(), p.inputName()+".pgm")
+}
+
+// inputName is the name of the input image without its extension, <width>x<height>, as the io goroutine
+// is asked for it.
+func (p Params) inputName() string {
+	return fmt.Sprintf("%vx%v", p.ImageWidth, p.ImageHeight)
+}
+
+// rule is p.Rule, or Conway if it is not set.
+func (p Params) rule() Rule {
+	return p.Rule.orConway()
+}
+
+// inputDir is the directory images are read from.
+func (p Params) inputDir() string {
+	if p.InputDir == "" {
+		return "images"
+	}
+	return p.InputDir
+}
+
+// outputDir is the directory images are saved to.
+func (p Params) outputDir() string {
+	if p.OutputDir == "" {
+		return "out"
+	}
+	return p.OutputDir
 }
 
+// ConwayRule is the birth/survival rule used by the engine, in B/S notation.
+const ConwayRule = "B3/S23"
+
 // Run starts the processing of Game of Life. It should initialise channels and goroutines.
+// Key presses are turned into Commands by KeyCommands; keyPresses may be nil.
 func Run(p Params, events chan<- Event, keyPresses <-chan rune) {
+	var commands chan Command
+	if keyPresses != nil {
+		commands = make(chan Command)
+		go KeyCommands(keyPresses, commands)
+	}
+	RunCommands(p, events, commands)
+}
+
+// RunCommands is Run controlled by Commands rather than key presses; commands may be nil.
+// Use a Controller to wait for each Command to be carried out.
+func RunCommands(p Params, events chan<- Event, commands <-chan Command) {
 
 	//	TODO: Put the missing channels in here.
 	ioCommand := make(chan ioCommand)
@@ -17,6 +89,7 @@ func Run(p Params, events chan<- Event, keyPresses <-chan rune) {
 	ioFilename := make(chan string, 1)
 	ioOutput := make(chan uint8)
 	ioInput := make(chan uint8)
+	ioRegion := make(chan ioRegion, 1)
 
 	ioChannels := ioChannels{
 		command:  ioCommand,
@@ -24,9 +97,21 @@ func Run(p Params, events chan<- Event, keyPresses <-chan rune) {
 		filename: ioFilename,
 		output:   ioOutput,
 		input:    ioInput,
+		region:   ioRegion,
 	}
 	go startIo(p, ioChannels)
 
+	if p.StatsEvery > 0 {
+		withStats := make(chan Event, cap(events))
+		go ReportStats(p, withStats, events)
+		events = withStats
+	}
+	if p.TrackEvery > 0 {
+		withShips := make(chan Event, cap(events))
+		go TrackSpaceships(p, withShips, events)
+		events = withShips
+	}
+
 	distributorChannels := DistributorChannels{
 		events:     events,
 		ioCommand:  ioCommand,
@@ -34,6 +119,8 @@ func Run(p Params, events chan<- Event, keyPresses <-chan rune) {
 		ioFilename: ioFilename,
 		ioOutput:   ioOutput,
 		IoInput:    ioInput,
+		ioRegion:   ioRegion,
+		commands:   commands,
 	}
 	distributor(p, distributorChannels)
 
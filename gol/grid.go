@@ -0,0 +1,218 @@
+package gol
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// Grid is the shape of the cells, which decides which cells are neighbours. Worlds of every grid are
+// stored as rows of cells, so images keep the same layout.
+type Grid uint8
+
+const (
+	// SquareGrid cells have eight neighbours, sharing an edge or a corner.
+	SquareGrid Grid = iota
+	// HexGrid cells have six neighbours. Rows are stored in offset layout, with odd rows shifted right by half
+	// a cell, so (x, y) touches (x-1, y-1) and (x, y-1) on even rows and (x, y-1) and (x+1, y-1) on odd rows.
+	// The height must be even for the world to wrap.
+	HexGrid
+	// TriangleGrid cells have twelve neighbours, sharing an edge or a corner. (x, y) points up when x+y is
+	// even and down otherwise, so each row alternates. The width and height must be even for the world to wrap.
+	TriangleGrid
+)
+
+func (grid Grid) String() string {
+	switch grid {
+	case SquareGrid:
+		return "square"
+	case HexGrid:
+		return "hex"
+	case TriangleGrid:
+		return "tri"
+	default:
+		return "Incorrect Grid"
+	}
+}
+
+// neighbourOffsets lists the neighbours of a cell for each grid. Hex cells depend on whether their row is
+// odd, and triangles on whether they point down.
+var neighbourOffsets = [...][2][]util.Cell{
+	SquareGrid: {squareOffsets, squareOffsets},
+	HexGrid: {
+		{{X: -1, Y: 0}, {X: 1, Y: 0}, {X: -1, Y: -1}, {X: 0, Y: -1}, {X: -1, Y: 1}, {X: 0, Y: 1}},
+		{{X: -1, Y: 0}, {X: 1, Y: 0}, {X: 0, Y: -1}, {X: 1, Y: -1}, {X: 0, Y: 1}, {X: 1, Y: 1}},
+	},
+	TriangleGrid: {
+		// Pointing up: three cells above the apex, four beside it and five below its base.
+		{
+			{X: -1, Y: -1}, {X: 0, Y: -1}, {X: 1, Y: -1},
+			{X: -2, Y: 0}, {X: -1, Y: 0}, {X: 1, Y: 0}, {X: 2, Y: 0},
+			{X: -2, Y: 1}, {X: -1, Y: 1}, {X: 0, Y: 1}, {X: 1, Y: 1}, {X: 2, Y: 1},
+		},
+		// Pointing down: the same upside down.
+		{
+			{X: -2, Y: -1}, {X: -1, Y: -1}, {X: 0, Y: -1}, {X: 1, Y: -1}, {X: 2, Y: -1},
+			{X: -2, Y: 0}, {X: -1, Y: 0}, {X: 1, Y: 0}, {X: 2, Y: 0},
+			{X: -1, Y: 1}, {X: 0, Y: 1}, {X: 1, Y: 1},
+		},
+	},
+}
+
+var squareOffsets = []util.Cell{
+	{X: -1, Y: -1}, {X: 0, Y: -1}, {X: 1, Y: -1},
+	{X: -1, Y: 0}, {X: 1, Y: 0},
+	{X: -1, Y: 1}, {X: 0, Y: 1}, {X: 1, Y: 1},
+}
+
+// Neighbours returns the offsets from (x, y) to each of its neighbours. Negative coordinates are allowed.
+func (grid Grid) Neighbours(x, y int) []util.Cell {
+	switch grid {
+	case HexGrid:
+		return neighbourOffsets[grid][y&1]
+	case TriangleGrid:
+		return neighbourOffsets[grid][(x+y)&1]
+	}
+	return squareOffsets
+}
+
+// Rule says which cells are alive on the next turn from the number of alive neighbours they have.
+// The zero value is Conway's Game of Life.
+type Rule struct {
+	Grid     Grid
+	Birth    uint16 // bit n is set if a dead cell with n alive neighbours comes alive
+	Survival uint16 // bit n is set if an alive cell with n alive neighbours stays alive
+}
+
+// Conway is B3/S23 on a square grid.
+var Conway = Rule{Grid: SquareGrid, Birth: 1 << 3, Survival: 1<<2 | 1<<3}
+
+// ParseRule reads a rule in B/S notation, such as "B3/S23", optionally after the name of a grid, such as
+// "hex B2/S34" or "tri B45/S34". Without one the grid is square. Neighbour counts are single digits.
+func ParseRule(s string) (Rule, error) {
+	var rule Rule
+	fields := strings.Fields(strings.ToUpper(s))
+	if len(fields) == 2 {
+		grid, ok := map[string]Grid{"SQUARE": SquareGrid, "HEX": HexGrid, "TRI": TriangleGrid}[fields[0]]
+		if !ok {
+			return rule, fmt.Errorf("rule %q: unknown grid %q, expected square, hex or tri", s, strings.ToLower(fields[0]))
+		}
+		rule.Grid = grid
+		fields = fields[1:]
+	}
+	parts := []string{}
+	if len(fields) == 1 {
+		parts = strings.Split(fields[0], "/")
+	}
+	if len(parts) != 2 || !strings.HasPrefix(parts[0], "B") || !strings.HasPrefix(parts[1], "S") {
+		return rule, fmt.Errorf("rule %q: expected B/S notation such as %v or hex B2/S34", s, ConwayRule)
+	}
+	neighbours := len(rule.Grid.Neighbours(0, 0))
+	for i, counts := range []*uint16{&rule.Birth, &rule.Survival} {
+		for _, c := range parts[i][1:] {
+			if c < '0' || int(c-'0') > neighbours {
+				return rule, fmt.Errorf("rule %q: %q is not a number of neighbours on a %v grid", s, c, rule.Grid)
+			}
+			*counts |= 1 << (c - '0')
+		}
+	}
+	return rule, nil
+}
+
+func (rule Rule) String() string {
+	rule = rule.orConway()
+	var b, s strings.Builder
+	for n := 0; n <= 12; n++ {
+		if rule.Birth&(1<<n) != 0 {
+			fmt.Fprint(&b, n)
+		}
+		if rule.Survival&(1<<n) != 0 {
+			fmt.Fprint(&s, n)
+		}
+	}
+	notation := fmt.Sprintf("B%v/S%v", b.String(), s.String())
+	if rule.Grid != SquareGrid {
+		return rule.Grid.String() + " " + notation
+	}
+	return notation
+}
+
+// orConway returns Conway for the zero Rule.
+func (rule Rule) orConway() Rule {
+	if rule == (Rule{}) {
+		return Conway
+	}
+	return rule
+}
+
+// CheckRule reports whether p.Rule can be used for the world p describes. A wrapped world must fit a whole
+// number of cells of the grid, and in an unbounded world cells cannot be born with no alive neighbours.
+func (p Params) CheckRule() error {
+	rule := p.rule()
+	switch {
+	case p.Unbounded && rule.Birth&1 != 0:
+		return fmt.Errorf("rule %v cannot be used in an unbounded world, as it has B0", rule)
+	case p.Unbounded:
+		return nil
+	case rule.Grid == HexGrid && p.ImageHeight%2 != 0:
+		return fmt.Errorf("a hex world must have an even height to wrap, not %v", p.ImageHeight)
+	case rule.Grid == TriangleGrid && (p.ImageWidth%2 != 0 || p.ImageHeight%2 != 0):
+		return fmt.Errorf("a triangular world must have an even width and height to wrap, not %vx%v",
+			p.ImageWidth, p.ImageHeight)
+	}
+	return nil
+}
+
+// nextWorldRule computes the next turn of a world that wraps around at its edges under any rule,
+// splitting the rows between threads as NextWorld does.
+func nextWorldRule(world [][]byte, rule Rule, threads int) [][]byte {
+	height := len(world)
+	next := make([][]byte, height)
+	for y := range next {
+		next[y] = make([]byte, len(world[y]))
+	}
+	if threads < 1 {
+		threads = 1
+	}
+	if threads > height {
+		threads = height
+	}
+	var wg sync.WaitGroup
+	for worker := 0; worker < threads; worker++ {
+		wg.Add(1)
+		go func(worker, startY, endY int) {
+			defer wg.Done()
+			start := time.Now()
+			nextStripRule(world, next, rule, startY, endY)
+			ObserveStep(worker, time.Since(start))
+		}(worker, worker*height/threads, (worker+1)*height/threads)
+	}
+	wg.Wait()
+	return next
+}
+
+// nextStripRule writes rows startY to endY-1 of the next turn under rule into next.
+func nextStripRule(world, next [][]byte, rule Rule, startY, endY int) {
+	height := len(world)
+	width := len(world[0])
+	for y := startY; y < endY; y++ {
+		for x := 0; x < width; x++ {
+			count := 0
+			for _, offset := range rule.Grid.Neighbours(x, y) {
+				count += alive(world[(y+offset.Y+height)%height][(x+offset.X+width)%width])
+			}
+			counts := rule.Birth
+			if world[y][x] != 0 {
+				counts = rule.Survival
+			}
+			if counts&(1<<count) != 0 {
+				next[y][x] = 255
+			} else {
+				next[y][x] = 0
+			}
+		}
+	}
+}
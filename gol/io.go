@@ -3,8 +3,10 @@ package gol
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 	"uk.ac.bris.cs/gameoflife/util"
 )
 
@@ -20,6 +22,13 @@ type ioChannels struct {
 	filename <-chan string
 	output   <-chan uint8
 	input    chan<- uint8
+	region   <-chan ioRegion
+}
+
+// ioRegion is the part of an unbounded world saved by ioOutputRegion: Width x Height cells whose
+// top-left cell is at (X, Y).
+type ioRegion struct {
+	X, Y, Width, Height int
 }
 
 // ioState is the internal ioState of the io goroutine.
@@ -37,39 +46,35 @@ type ioCommand uint8
 //	ioOutput 	= 0
 //	ioInput 	= 1
 //	ioCheckIdle = 2
+//	ioOutputRegion = 3
 const (
 	ioOutput ioCommand = iota
 	ioInput
 	ioCheckIdle
+	ioOutputRegion
 )
 
 // writePgmImage receives an array of bytes and writes it to a pgm file.
-func (io *ioState) writePgmImage() {
-	_ = os.Mkdir("out", os.ModePerm)
+// A region of an unbounded world records where its top-left cell is in the file, and a rule other than
+// Conway's is recorded too, for readPgmImage to check.
+func (io *ioState) writePgmImage(region *ioRegion) {
+	start := time.Now()
+	_ = os.MkdirAll(io.params.outputDir(), os.ModePerm)
 
 	// Request a filename from the distributor.
 	filename := <-io.channels.filename
 
-	file, ioError := os.Create("out/" + filename + ".pgm")
-	util.Check(ioError)
-	defer file.Close()
-
-	_, _ = file.WriteString("P5\n")
-	//_, _ = file.WriteString("# PGM file writer by pnmmodules (https://github.com/owainkenwayucl/pnmmodules).\n")
-	_, _ = file.WriteString(strconv.Itoa(io.params.ImageWidth))
-	_, _ = file.WriteString(" ")
-	_, _ = file.WriteString(strconv.Itoa(io.params.ImageHeight))
-	_, _ = file.WriteString("\n")
-	_, _ = file.WriteString(strconv.Itoa(255))
-	_, _ = file.WriteString("\n")
-
-	world := make([][]byte, io.params.ImageHeight)
+	height, width := io.params.ImageHeight, io.params.ImageWidth
+	if region != nil {
+		height, width = region.Height, region.Width
+	}
+	world := make([][]byte, height)
 	for i := range world {
-		world[i] = make([]byte, io.params.ImageWidth)
+		world[i] = make([]byte, width)
 	}
 
-	for y := 0; y < io.params.ImageHeight; y++ {
-		for x := 0; x < io.params.ImageWidth; x++ {
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
 			val := <-io.channels.output
 			//if val != 0 {
 			//	fmt.Println(x, y)
@@ -78,57 +83,217 @@ func (io *ioState) writePgmImage() {
 		}
 	}
 
-	for y := 0; y < io.params.ImageHeight; y++ {
-		for x := 0; x < io.params.ImageWidth; x++ {
-			_, ioError = file.Write([]byte{world[y][x]})
-			util.Check(ioError)
-		}
+	path := filepath.Join(io.params.outputDir(), filename+".pgm")
+	comment := ""
+	if region != nil {
+		comment = fmt.Sprintf("# offset %v %v\n", region.X, region.Y)
 	}
-
-	ioError = file.Sync()
-	util.Check(ioError)
+	util.Check(writePgm(path, world, comment+ruleComment(io.params.rule())))
+	ioSaveSeconds.Observe(time.Since(start).Seconds())
 
 	fmt.Println("File", filename, "output done!")
 }
 
 // readPgmImage opens a pgm file and sends its data as an array of bytes.
+// An image that records a rule must have been saved under the rule of this run.
 func (io *ioState) readPgmImage() {
 
 	// Request a filename from the distributor.
 	filename := <-io.channels.filename
 
-	data, ioError := os.ReadFile("images/" + filename + ".pgm")
+	path := filepath.Join(io.params.inputDir(), filename+".pgm")
+	world, _, rule, ioError := readPgm(path)
 	util.Check(ioError)
+	if rule != nil && *rule != io.params.rule() {
+		util.Check(fmt.Errorf("%v: saved under rule %v, but this run uses %v", path, *rule, io.params.rule()))
+	}
 
-	fields := strings.Fields(string(data))
+	if len(world) != io.params.ImageHeight {
+		panic("Incorrect height")
+	}
+	if len(world[0]) != io.params.ImageWidth {
+		panic("Incorrect width")
+	}
 
-	if fields[0] != "P5" {
-		panic("Not a pgm file")
+	// you give the command that you want to read an image, give it the filename
+	// you then receive the image byte-by-byte by the IO goroutines
+	for _, row := range world {
+		for _, b := range row {
+			io.channels.input <- b
+		}
 	}
 
-	width, _ := strconv.Atoi(fields[1])
-	if width != io.params.ImageWidth {
-		panic("Incorrect width")
+	fmt.Println("File", filename, "input done!")
+}
+
+// ReadPgm reads a binary (P5) PGM image with a maxval of 255, returning its pixels one row per line.
+func ReadPgm(path string) ([][]byte, error) {
+	world, _, err := ReadPgmAt(path)
+	return world, err
+}
+
+// ReadPgmAt is ReadPgm for a snapshot of an unbounded world, also returning the coordinates of its
+// top-left cell from an "# offset X Y" comment. Images without one are at (0, 0).
+func ReadPgmAt(path string) ([][]byte, util.Cell, error) {
+	world, offset, _, err := readPgm(path)
+	return world, offset, err
+}
+
+// readPgm is ReadPgmAt also returning the rule from an "# rule ..." comment, or nil for images without one.
+func readPgm(path string) ([][]byte, util.Cell, *Rule, error) {
+	var offset util.Cell
+	var rule *Rule
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, offset, rule, err
+	}
+	fields, pos := readHeader(data, 4)
+	for _, line := range strings.Split(string(data[:pos]), "\n") {
+		if strings.HasPrefix(line, "# offset ") {
+			if _, err := fmt.Sscanf(line, "# offset %d %d", &offset.X, &offset.Y); err != nil {
+				return nil, offset, rule, fmt.Errorf("%v: bad offset %q", path, line)
+			}
+		}
+		if strings.HasPrefix(line, "# rule ") {
+			r, err := ParseRule(strings.TrimPrefix(line, "# rule "))
+			if err != nil {
+				return nil, offset, rule, fmt.Errorf("%v: %v", path, err)
+			}
+			rule = &r
+		}
+	}
+	if len(fields) < 4 || fields[0] != "P5" {
+		return nil, offset, rule, fmt.Errorf("%v: not a binary pgm file", path)
+	}
+	width, errW := strconv.Atoi(fields[1])
+	height, errH := strconv.Atoi(fields[2])
+	if errW != nil || errH != nil || width <= 0 || height <= 0 {
+		return nil, offset, rule, fmt.Errorf("%v: bad size %vx%v", path, fields[1], fields[2])
+	}
+	if fields[3] != "255" {
+		return nil, offset, rule, fmt.Errorf("%v: incorrect maxval/bit depth %v", path, fields[3])
 	}
+	if pos >= len(data) {
+		return nil, offset, rule, fmt.Errorf("%v: no pixels after the header", path)
+	}
+	pixels := data[pos+1:]
+	if len(pixels) < width*height {
+		return nil, offset, rule, fmt.Errorf("%v: expected %v pixels, found %v", path, width*height, len(pixels))
+	}
+	world := make([][]byte, height)
+	for y := range world {
+		world[y] = append([]byte(nil), pixels[y*width:(y+1)*width]...)
+	}
+	return world, offset, rule, nil
+}
 
-	height, _ := strconv.Atoi(fields[2])
-	if height != io.params.ImageHeight {
-		panic("Incorrect height")
+// readHeader reads count whitespace-separated fields, possibly with # comments, from the start of a
+// PGM or PBM file. It returns them and the position of the single whitespace byte that follows.
+func readHeader(data []byte, count int) ([]string, int) {
+	var fields []string
+	pos := 0
+	for len(fields) < count && pos < len(data) {
+		switch c := data[pos]; {
+		case c == '#':
+			for pos < len(data) && data[pos] != '\n' {
+				pos++
+			}
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			pos++
+		default:
+			start := pos
+			for pos < len(data) && !strings.ContainsRune(" \t\n\r", rune(data[pos])) {
+				pos++
+			}
+			fields = append(fields, string(data[start:pos]))
+		}
 	}
+	return fields, pos
+}
 
-	maxval, _ := strconv.Atoi(fields[3])
-	if maxval != 255 {
-		panic("Incorrect maxval/bit depth")
+// WritePgm writes world as a binary PGM image with a maxval of 255.
+func WritePgm(path string, world [][]byte) error {
+	return writePgm(path, world, "")
+}
+
+// WritePgmAt writes part of an unbounded world whose top-left cell is at offset, recording the offset
+// in a comment for ReadPgmAt.
+func WritePgmAt(path string, world [][]byte, offset util.Cell) error {
+	return writePgm(path, world, fmt.Sprintf("# offset %v %v\n", offset.X, offset.Y))
+}
+
+// ruleComment records a rule other than Conway's in a PGM comment, as the same cells mean something
+// else on another grid or under another rule.
+func ruleComment(rule Rule) string {
+	if rule.orConway() == Conway {
+		return ""
 	}
+	return fmt.Sprintf("# rule %v\n", rule)
+}
 
-	image := []byte(fields[4])
-	// you give the command that you want to read an image, give it the filename
-	// you then receive the image byte-by-byte by the IO goroutines
-	for _, b := range image {
-		io.channels.input <- b
+func writePgm(path string, world [][]byte, comment string) error {
+	width := 0
+	if len(world) > 0 {
+		width = len(world[0])
 	}
+	data := []byte(fmt.Sprintf("P5\n%v%v %v\n255\n", comment, width, len(world)))
+	for _, row := range world {
+		data = append(data, row...)
+	}
+	return os.WriteFile(path, data, 0666)
+}
 
-	fmt.Println("File", filename, "input done!")
+// ReadPlaintext reads a pattern in plaintext format: 'O' for alive, '.' for dead and '!' for comment lines.
+// Short rows are padded with dead cells.
+func ReadPlaintext(path string) ([][]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var world [][]byte
+	width := 0
+	for _, line := range strings.Split(strings.ReplaceAll(string(data), "\r", ""), "\n") {
+		if strings.HasPrefix(line, "!") {
+			continue
+		}
+		row := make([]byte, len(line))
+		for x, c := range line {
+			switch c {
+			case 'O', '*':
+				row[x] = 255
+			case '.':
+			default:
+				return nil, fmt.Errorf("%v: unexpected %q in row %v", path, c, len(world)+1)
+			}
+		}
+		if len(row) > width {
+			width = len(row)
+		}
+		world = append(world, row)
+	}
+	for len(world) > 0 && len(world[len(world)-1]) == 0 {
+		world = world[:len(world)-1]
+	}
+	for y, row := range world {
+		world[y] = append(row, make([]byte, width-len(row))...)
+	}
+	return world, nil
+}
+
+// WritePlaintext writes world in plaintext format.
+func WritePlaintext(path string, world [][]byte) error {
+	var lines []string
+	for _, row := range world {
+		line := make([]byte, len(row))
+		for x, cell := range row {
+			line[x] = '.'
+			if cell != 0 {
+				line[x] = 'O'
+			}
+		}
+		lines = append(lines, string(line))
+	}
+	return os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0666)
 }
 
 // startIo should be the entrypoint of the io goroutine.
@@ -145,7 +310,10 @@ func startIo(p Params, c ioChannels) {
 		case ioInput:
 			io.readPgmImage()
 		case ioOutput:
-			io.writePgmImage()
+			io.writePgmImage(nil)
+		case ioOutputRegion:
+			region := <-io.channels.region
+			io.writePgmImage(&region)
 			// checkIdle ensures you don't close the program before writePGM has finished writing
 		case ioCheckIdle:
 			io.channels.idle <- true
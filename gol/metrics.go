@@ -0,0 +1,43 @@
+package gol
+
+import (
+	"strconv"
+	"time"
+
+	"uk.ac.bris.cs/gameoflife/metrics"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// Metrics exported by the controller. Serve them with metrics.Serve.
+var (
+	turnsCompleted = metrics.NewCounter("gol_turns_completed_total", "Number of turns completed.")
+	turnsPerSecond = metrics.NewGauge("gol_turns_per_second", "Average turns per second over recent AliveCellsCount reports.")
+	aliveCells     = metrics.NewGauge("gol_alive_cells", "Number of alive cells at the last AliveCellsCount report.")
+	stepSeconds    = metrics.NewHistogram("gol_step_seconds", "Time taken by a worker to compute one turn.", nil, "worker")
+	ioSaveSeconds  = metrics.NewHistogram("gol_io_save_seconds", "Time taken to write a PGM image.", nil)
+)
+
+// RecordMetrics updates the turn and alive cell metrics from events until the channel is closed.
+// It only needs TurnComplete and AliveCellsCount, so it can use a filtered Bus subscription.
+func RecordMetrics(events <-chan Event) {
+	avgTurns := util.NewAvgTurns()
+	lastTurn := 0
+	for event := range events {
+		switch e := event.(type) {
+		case TurnComplete, AliveCellsCount:
+			if turn := e.GetCompletedTurns(); turn > lastTurn {
+				turnsCompleted.Add(float64(turn - lastTurn))
+				lastTurn = turn
+			}
+		}
+		if e, ok := event.(AliveCellsCount); ok {
+			aliveCells.Set(float64(e.CellsCount))
+			turnsPerSecond.Set(float64(avgTurns.Get(e.CompletedTurns)))
+		}
+	}
+}
+
+// ObserveStep records how long the given worker took to compute a turn.
+func ObserveStep(worker int, duration time.Duration) {
+	stepSeconds.Observe(duration.Seconds(), strconv.Itoa(worker))
+}
@@ -0,0 +1,43 @@
+package gol
+
+import "uk.ac.bris.cs/gameoflife/util"
+
+// mirror rebuilds the board from CellFlipped and CellsFlipped events, so features built on it
+// work the same whichever engine produced the events.
+type mirror struct {
+	width, height int
+	world         [][]bool
+	alive         int
+}
+
+func newMirror(width, height int) mirror {
+	world := make([][]bool, height)
+	for i := range world {
+		world[i] = make([]bool, width)
+	}
+	return mirror{width: width, height: height, world: world}
+}
+
+// flip toggles a cell and returns whether it is now alive.
+func (m *mirror) flip(cell util.Cell) bool {
+	m.world[cell.Y][cell.X] = !m.world[cell.Y][cell.X]
+	if m.world[cell.Y][cell.X] {
+		m.alive++
+	} else {
+		m.alive--
+	}
+	return m.world[cell.Y][cell.X]
+}
+
+// aliveCells lists the live cells in row order.
+func (m *mirror) aliveCells() []util.Cell {
+	cells := make([]util.Cell, 0, m.alive)
+	for y, row := range m.world {
+		for x, alive := range row {
+			if alive {
+				cells = append(cells, util.Cell{X: x, Y: y})
+			}
+		}
+	}
+	return cells
+}
@@ -0,0 +1,212 @@
+package gol
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// EngineKind picks how the distributor computes turns.
+type EngineKind uint8
+
+const (
+	// AutoEngine uses the sparse engine while few cells are alive and the dense engine otherwise.
+	AutoEngine EngineKind = iota
+	// DenseEngine computes the world tile by tile with a TiledWorld, skipping tiles that cannot change.
+	DenseEngine
+	// SparseEngine only visits alive cells and their neighbours.
+	SparseEngine
+)
+
+// The auto engine switches to the sparse engine when the density of alive cells drops below
+// sparseBelow divided by the number of threads the dense engine can use at once, and back to the
+// dense engine at twice that density. The gap stops it switching back and forth every turn.
+const sparseBelow = 0.025
+
+func (kind EngineKind) String() string {
+	switch kind {
+	case AutoEngine:
+		return "auto"
+	case DenseEngine:
+		return "dense"
+	case SparseEngine:
+		return "sparse"
+	default:
+		return "Incorrect EngineKind"
+	}
+}
+
+// MarshalText and UnmarshalText let an EngineKind be written by name, e.g. in config files.
+func (kind EngineKind) MarshalText() ([]byte, error) {
+	return []byte(kind.String()), nil
+}
+
+func (kind *EngineKind) UnmarshalText(text []byte) error {
+	for k := AutoEngine; k <= SparseEngine; k++ {
+		if k.String() == string(text) {
+			*kind = k
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown engine %q, expected auto, dense or sparse", text)
+}
+
+// sparseWorld is the list of alive cells of a world that wraps around at its edges. Cells are numbered
+// y*width+x. Neighbour counts are kept in an array the size of the world, but only the entries next to
+// alive cells are touched, so a turn takes time in proportion to the number of alive cells.
+type sparseWorld struct {
+	width, height int
+	alive         []int
+	isAlive       []bool
+	neighbours    []uint8
+	touched       []int
+}
+
+func newSparseWorld(world [][]byte) *sparseWorld {
+	s := &sparseWorld{height: len(world)}
+	if s.height > 0 {
+		s.width = len(world[0])
+	}
+	s.isAlive = make([]bool, s.width*s.height)
+	s.neighbours = make([]uint8, s.width*s.height)
+	for y, row := range world {
+		for x, cell := range row {
+			if cell != 0 {
+				s.alive = append(s.alive, y*s.width+x)
+				s.isAlive[y*s.width+x] = true
+			}
+		}
+	}
+	return s
+}
+
+// next computes the next turn by counting the alive neighbours of every cell next to an alive cell,
+// and returns the cells that flipped in row-major order, as NextWorld would find them.
+func (s *sparseWorld) next() []util.Cell {
+	s.touched = s.touched[:0]
+	for _, i := range s.alive {
+		x, y := i%s.width, i/s.width
+		for dy := -1; dy <= 1; dy++ {
+			row := (y + dy + s.height) % s.height * s.width
+			for dx := -1; dx <= 1; dx++ {
+				if dx == 0 && dy == 0 {
+					continue
+				}
+				j := row + (x+dx+s.width)%s.width
+				if s.neighbours[j] == 0 {
+					s.touched = append(s.touched, j)
+				}
+				s.neighbours[j]++
+			}
+		}
+	}
+
+	var flips []int
+	for _, i := range s.alive {
+		if count := s.neighbours[i]; count != 2 && count != 3 {
+			flips = append(flips, i)
+		}
+	}
+	for _, j := range s.touched {
+		if s.neighbours[j] == 3 && !s.isAlive[j] {
+			flips = append(flips, j)
+		}
+		s.neighbours[j] = 0
+	}
+	sort.Ints(flips)
+
+	flipped := make([]util.Cell, len(flips))
+	for n, i := range flips {
+		flipped[n] = util.Cell{X: i % s.width, Y: i / s.width}
+		s.isAlive[i] = !s.isAlive[i]
+	}
+	alive := s.alive[:0]
+	for _, i := range s.alive {
+		if s.isAlive[i] {
+			alive = append(alive, i)
+		}
+	}
+	for _, i := range flips {
+		if s.isAlive[i] {
+			alive = append(alive, i)
+		}
+	}
+	s.alive = alive
+	return flipped
+}
+
+// flip makes a dead cell alive or an alive cell dead.
+func (s *sparseWorld) flip(cell util.Cell) {
+	i := cell.Y*s.width + cell.X
+	s.isAlive[i] = !s.isAlive[i]
+	if s.isAlive[i] {
+		s.alive = append(s.alive, i)
+		return
+	}
+	for n, j := range s.alive {
+		if j == i {
+			s.alive = append(s.alive[:n], s.alive[n+1:]...)
+			return
+		}
+	}
+}
+
+// nextTurn computes the turn after l.world with the engine picked by l.p.Engine, or with l.universe if the
+// world is unbounded, and returns the cells that flipped. Rules other than Conway's compute the whole world.
+// While the sparse engine is in use l.world is updated in place, and while the dense engine is in use l.world
+// swaps between the two worlds of l.tiles.
+func (l *turnLoop) nextTurn() []util.Cell {
+	if l.universe != nil {
+		return l.universe.next()
+	}
+	if rule := l.p.rule(); rule != Conway {
+		next := nextWorldRule(l.world, rule, l.p.Threads)
+		var flipped []util.Cell
+		for y := range next {
+			for x := range next[y] {
+				if next[y][x] != l.world[y][x] {
+					flipped = append(flipped, util.Cell{X: x, Y: y})
+				}
+			}
+		}
+		l.world = next
+		return flipped
+	}
+	parallel := l.p.Threads
+	if parallel > runtime.NumCPU() {
+		parallel = runtime.NumCPU()
+	}
+	if parallel < 1 {
+		parallel = 1
+	}
+	threshold := sparseBelow / float64(parallel)
+	density := float64(l.alive) / float64(l.p.ImageWidth*l.p.ImageHeight)
+	switch {
+	case l.p.Engine == DenseEngine || (l.p.Engine == AutoEngine && density > 2*threshold):
+		l.sparse = nil
+	case l.sparse == nil && (l.p.Engine == SparseEngine || density < threshold):
+		l.sparse = newSparseWorld(l.world)
+		l.tiles = nil
+	}
+
+	if l.sparse != nil {
+		flipped := l.sparse.next()
+		for _, cell := range flipped {
+			if l.world[cell.Y][cell.X] != 0 {
+				l.world[cell.Y][cell.X] = 0
+			} else {
+				l.world[cell.Y][cell.X] = 255
+			}
+		}
+		return flipped
+	}
+
+	if l.tiles == nil {
+		l.tiles = NewTiledWorld(l.world, l.p.Threads)
+	}
+	flipped := l.tiles.Next()
+	l.world = l.tiles.World()
+	return flipped
+}
@@ -0,0 +1,159 @@
+package gol
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// StatsRegions is the number of regions along each side of the board used for PopulationStats.Density.
+const StatsRegions = 4
+
+// statsTracker works out statistics from a mirror of the board.
+type statsTracker struct {
+	mirror
+	flipsTurn int
+	births    int
+	deaths    int
+}
+
+// ReportStats forwards every event from in to out, adding a PopulationStats event after every
+// p.StatsEvery-th TurnComplete. It closes out once in is closed.
+func ReportStats(p Params, in <-chan Event, out chan<- Event) {
+	defer close(out)
+	tracker := newStatsTracker(p.ImageWidth, p.ImageHeight)
+	for event := range in {
+		tracker.update(event)
+		out <- event
+		if e, ok := event.(TurnComplete); ok && e.CompletedTurns%p.StatsEvery == 0 {
+			out <- tracker.stats(e.CompletedTurns)
+		}
+	}
+}
+
+func newStatsTracker(width, height int) *statsTracker {
+	return &statsTracker{mirror: newMirror(width, height)}
+}
+
+func (t *statsTracker) update(event Event) {
+	cells, ok := flippedCells(event)
+	if !ok {
+		return
+	}
+	if event.GetCompletedTurns() != t.flipsTurn {
+		t.flipsTurn = event.GetCompletedTurns()
+		t.births = 0
+		t.deaths = 0
+	}
+	for _, cell := range cells {
+		if t.flip(cell) {
+			t.births++
+		} else {
+			t.deaths++
+		}
+	}
+}
+
+func (t *statsTracker) stats(turn int) PopulationStats {
+	stats := PopulationStats{
+		CompletedTurns: turn,
+		Alive:          t.alive,
+		MinX:           -1,
+		MinY:           -1,
+		MaxX:           -1,
+		MaxY:           -1,
+		Density:        make([][]float64, StatsRegions),
+	}
+	if t.flipsTurn == turn {
+		stats.Births = t.births
+		stats.Deaths = t.deaths
+	}
+	counts := make([][]int, StatsRegions)
+	for i := range counts {
+		counts[i] = make([]int, StatsRegions)
+		stats.Density[i] = make([]float64, StatsRegions)
+	}
+
+	sumX, sumY := 0, 0
+	for y, row := range t.world {
+		for x, alive := range row {
+			if !alive {
+				continue
+			}
+			if stats.MinX == -1 || x < stats.MinX {
+				stats.MinX = x
+			}
+			if x > stats.MaxX {
+				stats.MaxX = x
+			}
+			if stats.MinY == -1 {
+				stats.MinY = y
+			}
+			stats.MaxY = y
+			sumX += x
+			sumY += y
+			counts[y*StatsRegions/t.height][x*StatsRegions/t.width]++
+		}
+	}
+	if t.alive > 0 {
+		stats.CentreX = float64(sumX) / float64(t.alive)
+		stats.CentreY = float64(sumY) / float64(t.alive)
+	}
+
+	for i := 0; i < StatsRegions; i++ {
+		for j := 0; j < StatsRegions; j++ {
+			regionHeight := (i+1)*t.height/StatsRegions - i*t.height/StatsRegions
+			regionWidth := (j+1)*t.width/StatsRegions - j*t.width/StatsRegions
+			if area := regionWidth * regionHeight; area > 0 {
+				stats.Density[i][j] = float64(counts[i][j]) / float64(area)
+			}
+		}
+	}
+	return stats
+}
+
+// WriteStatsCSV writes every PopulationStats event from events to w as CSV until the channel is closed.
+// The first two columns match the check/alive/*.csv files, so the same tools can read either.
+func WriteStatsCSV(events <-chan Event, w io.Writer) error {
+	writer := csv.NewWriter(w)
+	header := []string{"completed_turns", "alive_cells", "births", "deaths",
+		"min_x", "min_y", "max_x", "max_y", "centre_x", "centre_y"}
+	for i := 0; i < StatsRegions; i++ {
+		for j := 0; j < StatsRegions; j++ {
+			header = append(header, fmt.Sprintf("density_%v_%v", i, j))
+		}
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	for event := range events {
+		stats, ok := event.(PopulationStats)
+		if !ok {
+			continue
+		}
+		row := []string{
+			strconv.Itoa(stats.CompletedTurns),
+			strconv.Itoa(stats.Alive),
+			strconv.Itoa(stats.Births),
+			strconv.Itoa(stats.Deaths),
+			strconv.Itoa(stats.MinX),
+			strconv.Itoa(stats.MinY),
+			strconv.Itoa(stats.MaxX),
+			strconv.Itoa(stats.MaxY),
+			strconv.FormatFloat(stats.CentreX, 'f', 3, 64),
+			strconv.FormatFloat(stats.CentreY, 'f', 3, 64),
+		}
+		for _, densities := range stats.Density {
+			for _, density := range densities {
+				row = append(row, strconv.FormatFloat(density, 'f', 4, 64))
+			}
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+		writer.Flush()
+	}
+	writer.Flush()
+	return writer.Error()
+}
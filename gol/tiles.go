@@ -0,0 +1,248 @@
+package gol
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TileSize is the width and height of the tiles a TiledWorld is split into.
+const TileSize = 8
+
+// allTiles has the bits for a tile and the eight around it. Bit (dy+1)*3+dx+1 is the tile dx across and dy down.
+const allTiles = 1<<9 - 1
+
+// TiledWorld computes turns of a world that wraps around at its edges, split into tiles. Only tiles that
+// changed on the last turn, or that touch a cell that changed, can change on the next, so stable areas such
+// as still lifes and empty space are skipped. The tiles that are computed are shared between threads, and a
+// thread that runs out of its own tiles takes them from the others.
+type TiledWorld struct {
+	width, height int
+	columns, rows int // the number of tiles across and down
+	threads       int
+
+	// world is the current turn and next the one before it. Tiles that did not change on the last turn
+	// are the same in both, so next only needs the computed tiles writing to become the next turn.
+	world, next [][]byte
+	changed     []uint16 // for each tile, a bit for each tile around it, itself included, that its flips were next to
+	active      []bool
+	queues      []tileQueue
+	flips       [][]util.Cell // the cells that flipped in each tile, in row-major order
+}
+
+// tileQueue holds the tiles given to one thread. The thread takes tiles from the front and others steal
+// them from the back.
+type tileQueue struct {
+	mutex sync.Mutex
+	tiles []int
+}
+
+// NewTiledWorld starts from world, which it then updates in place, with up to threads threads.
+func NewTiledWorld(world [][]byte, threads int) *TiledWorld {
+	t := &TiledWorld{height: len(world), world: world, threads: threads}
+	if t.height > 0 {
+		t.width = len(world[0])
+	}
+	if t.threads < 1 {
+		t.threads = 1
+	}
+	t.columns = (t.width + TileSize - 1) / TileSize
+	t.rows = (t.height + TileSize - 1) / TileSize
+	t.next = make([][]byte, t.height)
+	for y := range t.next {
+		t.next[y] = make([]byte, t.width)
+	}
+	// Nothing is known about next yet, so every tile is computed on the first turn.
+	t.changed = make([]uint16, t.columns*t.rows)
+	for i := range t.changed {
+		t.changed[i] = allTiles
+	}
+	t.active = make([]bool, t.columns*t.rows)
+	t.queues = make([]tileQueue, t.threads)
+	t.flips = make([][]util.Cell, t.columns*t.rows)
+	return t
+}
+
+// World returns the current turn.
+func (t *TiledWorld) World() [][]byte {
+	return t.world
+}
+
+// Changed tells t that a cell of World was changed by the caller, so its tile is computed on the next turn.
+func (t *TiledWorld) Changed(cell util.Cell) {
+	t.changed[cell.Y/TileSize*t.columns+cell.X/TileSize] = allTiles
+}
+
+// Next computes the next turn and returns the cells that flipped in row-major order, as NextWorld would find them.
+func (t *TiledWorld) Next() []util.Cell {
+	var tiles []int
+	for i := range t.active {
+		t.active[i] = false
+	}
+	for i, changed := range t.changed {
+		if changed == 0 {
+			continue
+		}
+		column, row := i%t.columns, i/t.columns
+		for dy := -1; dy <= 1; dy++ {
+			for dx := -1; dx <= 1; dx++ {
+				j := (row+dy+t.rows)%t.rows*t.columns + (column+dx+t.columns)%t.columns
+				if changed&(1<<((dy+1)*3+dx+1)) != 0 && !t.active[j] {
+					t.active[j] = true
+					tiles = append(tiles, j)
+				}
+			}
+		}
+	}
+	for i := range t.changed {
+		t.changed[i] = 0
+	}
+	sort.Ints(tiles)
+
+	threads := t.threads
+	if threads > len(tiles) {
+		threads = len(tiles)
+	}
+	for worker := 0; worker < threads; worker++ {
+		t.queues[worker].tiles = tiles[worker*len(tiles)/threads : (worker+1)*len(tiles)/threads]
+	}
+	var wg sync.WaitGroup
+	for worker := 0; worker < threads; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			start := time.Now()
+			for {
+				tile, ok := t.queues[worker].pop()
+				for other := 1; !ok && other < threads; other++ {
+					tile, ok = t.queues[(worker+other)%threads].steal()
+				}
+				if !ok {
+					break
+				}
+				t.nextTile(tile)
+			}
+			ObserveStep(worker, time.Since(start))
+		}(worker)
+	}
+	wg.Wait()
+
+	t.world, t.next = t.next, t.world
+	return t.flipped(tiles)
+}
+
+// flipped joins the cells that flipped in each of tiles into one list in row-major order, and empties the
+// lists of each tile. tiles must be in order. The tiles in a row of tiles take turns to add the cells they
+// flipped in each row of cells.
+func (t *TiledWorld) flipped(tiles []int) []util.Cell {
+	var flipped []util.Cell
+	var rest [][]util.Cell
+	for len(tiles) > 0 {
+		row := tiles[0] / t.columns
+		rest = rest[:0]
+		for len(tiles) > 0 && tiles[0]/t.columns == row {
+			rest = append(rest, t.flips[tiles[0]])
+			t.flips[tiles[0]] = t.flips[tiles[0]][:0]
+			tiles = tiles[1:]
+		}
+		for y := row * TileSize; y < (row+1)*TileSize && y < t.height; y++ {
+			for i, flips := range rest {
+				n := 0
+				for n < len(flips) && flips[n].Y == y {
+					n++
+				}
+				flipped = append(flipped, flips[:n]...)
+				rest[i] = flips[n:]
+			}
+		}
+	}
+	return flipped
+}
+
+// nextTile writes a tile of the next turn into t.next and lists the cells that flipped.
+func (t *TiledWorld) nextTile(tile int) {
+	startX, startY := tile%t.columns*TileSize, tile/t.columns*TileSize
+	endX, endY := startX+TileSize, startY+TileSize
+	if endX > t.width {
+		endX = t.width
+	}
+	if endY > t.height {
+		endY = t.height
+	}
+	flips := t.flips[tile][:0]
+	changed := uint16(0)
+	left, right := (startX-1+t.width)%t.width, endX%t.width
+	var columns [TileSize + 2]int
+	for y := startY; y < endY; y++ {
+		up := t.world[(y-1+t.height)%t.height]
+		row := t.world[y]
+		down := t.world[(y+1)%t.height]
+		// columns holds the alive cells in the three rows of each column of the tile and the column either side.
+		columns[0] = alive(up[left]) + alive(row[left]) + alive(down[left])
+		for x := startX; x < endX; x++ {
+			columns[x-startX+1] = alive(up[x]) + alive(row[x]) + alive(down[x])
+		}
+		columns[endX-startX+1] = alive(up[right]) + alive(row[right]) + alive(down[right])
+
+		// Flips on the edges of the tile wake the tiles on the other side of them.
+		rows := uint16(0b010)
+		if y == startY {
+			rows |= 0b001
+		}
+		if y == endY-1 {
+			rows |= 0b100
+		}
+		next := t.next[y]
+		for x := startX; x < endX; x++ {
+			i := x - startX + 1
+			sum := columns[i-1] + columns[i] + columns[i+1] - alive(row[x])
+			if sum == 3 || (sum == 2 && row[x] != 0) {
+				next[x] = 255
+			} else {
+				next[x] = 0
+			}
+			if next[x] == row[x] {
+				continue
+			}
+			flips = append(flips, util.Cell{X: x, Y: y})
+			cols := uint16(0b010)
+			if x == startX {
+				cols |= 0b001
+			}
+			if x == endX-1 {
+				cols |= 0b100
+			}
+			for dy := uint16(0); dy < 3; dy++ {
+				if rows&(1<<dy) != 0 {
+					changed |= cols << (3 * dy)
+				}
+			}
+		}
+	}
+	t.flips[tile] = flips
+	t.changed[tile] = changed
+}
+
+func (q *tileQueue) pop() (int, bool) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if len(q.tiles) == 0 {
+		return 0, false
+	}
+	tile := q.tiles[0]
+	q.tiles = q.tiles[1:]
+	return tile, true
+}
+
+func (q *tileQueue) steal() (int, bool) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if len(q.tiles) == 0 {
+		return 0, false
+	}
+	tile := q.tiles[len(q.tiles)-1]
+	q.tiles = q.tiles[:len(q.tiles)-1]
+	return tile, true
+}
@@ -0,0 +1,116 @@
+package gol
+
+import (
+	"math"
+
+	"uk.ac.bris.cs/gameoflife/census"
+)
+
+// trackTolerance is how far, in cells, a spaceship may be from where its track predicted
+// and still be treated as the same object. Centres wobble by less than a cell between phases.
+const trackTolerance = 2.0
+
+// track is the last sighting of one spaceship.
+type track struct {
+	id     int
+	name   string
+	turn   int
+	x, y   float64
+	vx, vy float64
+}
+
+// shipTracker finds spaceships on a mirror of the board and follows them between sightings.
+type shipTracker struct {
+	mirror
+	nextID int
+	tracks []track
+}
+
+// TrackSpaceships forwards every event from in to out, adding a SpaceshipSeen event for each glider,
+// LWSS, MWSS or HWSS on the board after every p.TrackEvery-th TurnComplete. It closes out once in is closed.
+func TrackSpaceships(p Params, in <-chan Event, out chan<- Event) {
+	defer close(out)
+	tracker := &shipTracker{mirror: newMirror(p.ImageWidth, p.ImageHeight)}
+	for event := range in {
+		if cells, ok := flippedCells(event); ok {
+			for _, cell := range cells {
+				tracker.flip(cell)
+			}
+		}
+		out <- event
+		if e, ok := event.(TurnComplete); ok && e.CompletedTurns%p.TrackEvery == 0 {
+			for _, seen := range tracker.sight(e.CompletedTurns) {
+				out <- seen
+			}
+		}
+	}
+}
+
+// sight finds every spaceship on the board and matches it to the track that predicted it,
+// starting new tracks for the rest. Tracks that were not matched are forgotten.
+func (t *shipTracker) sight(turn int) []SpaceshipSeen {
+	var seen []SpaceshipSeen
+	var tracks []track
+	matched := make([]bool, len(t.tracks))
+	for _, object := range census.Objects(t.aliveCells(), t.width, t.height) {
+		if object.Kind != census.Spaceship {
+			continue
+		}
+		x, y := census.Centre(object.Cells)
+		next := track{
+			name: object.Name,
+			turn: turn,
+			x:    wrapFloat(x, t.width),
+			y:    wrapFloat(y, t.height),
+		}
+		next.vx, next.vy = census.Velocity(object)
+		next.id = -1
+		for i, previous := range t.tracks {
+			if matched[i] || previous.name != next.name {
+				continue
+			}
+			elapsed := float64(turn - previous.turn)
+			dx := torusDistance(previous.x+previous.vx*elapsed, next.x, t.width)
+			dy := torusDistance(previous.y+previous.vy*elapsed, next.y, t.height)
+			if math.Hypot(dx, dy) <= trackTolerance {
+				matched[i] = true
+				next.id = previous.id
+				break
+			}
+		}
+		if next.id == -1 {
+			next.id = t.nextID
+			t.nextID++
+		}
+		tracks = append(tracks, next)
+		seen = append(seen, SpaceshipSeen{
+			CompletedTurns: turn,
+			ID:             next.id,
+			Name:           next.name,
+			X:              next.x,
+			Y:              next.y,
+			VX:             next.vx,
+			VY:             next.vy,
+		})
+	}
+	t.tracks = tracks
+	return seen
+}
+
+// wrapFloat moves a coordinate onto a board of the given size.
+func wrapFloat(v float64, size int) float64 {
+	v = math.Mod(v, float64(size))
+	if v < 0 {
+		v += float64(size)
+	}
+	return v
+}
+
+// torusDistance is the shortest signed distance from a to b around a board of the given size.
+func torusDistance(a, b float64, size int) float64 {
+	d := wrapFloat(b-a, size)
+	if d > float64(size)/2 {
+		d -= float64(size)
+	}
+	return d
+}
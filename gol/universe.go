@@ -0,0 +1,110 @@
+package gol
+
+import (
+	"sort"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// universe is the set of alive cells of an unbounded world, which grows as patterns expand instead of
+// wrapping at its edges. Cells may have any coordinates, including negative ones. Like sparseWorld, a
+// turn only visits alive cells and their neighbours, so rules in which cells with no alive neighbours are
+// born cannot be used.
+type universe struct {
+	rule       Rule
+	alive      map[util.Cell]bool
+	neighbours map[util.Cell]uint8 // emptied after every turn, but kept so its buckets are reused
+}
+
+// newUniverse starts from the alive cells of world, which keep their coordinates.
+func newUniverse(world [][]byte, rule Rule) *universe {
+	u := &universe{rule: rule, alive: make(map[util.Cell]bool), neighbours: make(map[util.Cell]uint8)}
+	for y, row := range world {
+		for x, cell := range row {
+			if cell != 0 {
+				u.alive[util.Cell{X: x, Y: y}] = true
+			}
+		}
+	}
+	return u
+}
+
+// next computes the next turn and returns the cells that flipped in row-major order.
+func (u *universe) next() []util.Cell {
+	for cell := range u.alive {
+		for _, offset := range u.rule.Grid.Neighbours(cell.X, cell.Y) {
+			u.neighbours[util.Cell{X: cell.X + offset.X, Y: cell.Y + offset.Y}]++
+		}
+	}
+	var flipped []util.Cell
+	for cell := range u.alive {
+		if u.rule.Survival&(1<<u.neighbours[cell]) == 0 {
+			flipped = append(flipped, cell)
+		}
+	}
+	for cell, count := range u.neighbours {
+		if u.rule.Birth&(1<<count) != 0 && !u.alive[cell] {
+			flipped = append(flipped, cell)
+		}
+		delete(u.neighbours, cell)
+	}
+	sortCells(flipped)
+	for _, cell := range flipped {
+		u.flip(cell)
+	}
+	return flipped
+}
+
+// flip makes a dead cell alive or an alive cell dead.
+func (u *universe) flip(cell util.Cell) {
+	if u.alive[cell] {
+		delete(u.alive, cell)
+	} else {
+		u.alive[cell] = true
+	}
+}
+
+// cells lists the alive cells in row-major order.
+func (u *universe) cells() []util.Cell {
+	cells := make([]util.Cell, 0, len(u.alive))
+	for cell := range u.alive {
+		cells = append(cells, cell)
+	}
+	sortCells(cells)
+	return cells
+}
+
+// crop returns the smallest world holding every alive cell and the coordinates of its top-left cell.
+// A universe with no alive cells is cropped to a single dead cell at (0, 0).
+func (u *universe) crop() ([][]byte, util.Cell) {
+	min, max := Bounds(u.cells())
+	world := make([][]byte, max.Y-min.Y+1)
+	for y := range world {
+		world[y] = make([]byte, max.X-min.X+1)
+	}
+	for cell := range u.alive {
+		world[cell.Y-min.Y][cell.X-min.X] = 255
+	}
+	return world, min
+}
+
+// Bounds returns the top-left and bottom-right corners of the smallest rectangle holding every cell,
+// or (0, 0) for both if there are none.
+func Bounds(cells []util.Cell) (min, max util.Cell) {
+	if len(cells) == 0 {
+		return
+	}
+	min, max = cells[0], cells[0]
+	for _, cell := range cells[1:] {
+		min.X, max.X = minInt(min.X, cell.X), maxInt(max.X, cell.X)
+		min.Y, max.Y = minInt(min.Y, cell.Y), maxInt(max.Y, cell.Y)
+	}
+	return
+}
+
+// sortCells puts cells in row-major order, the order NextWorld finds them in.
+func sortCells(cells []util.Cell) {
+	sort.Slice(cells, func(i, j int) bool {
+		return cells[i].Y < cells[j].Y || (cells[i].Y == cells[j].Y && cells[i].X < cells[j].X)
+	})
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/config"
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestParseRule checks rules on each grid are read and written back in the same notation.
+func TestParseRule(t *testing.T) {
+	for _, notation := range []string{"B3/S23", "B36/S23", "hex B2/S34", "tri B45/S34", "hex B2/S"} {
+		rule, err := gol.ParseRule(notation)
+		util.Check(err)
+		assert(t, rule.String() == notation, "Rule %v was written back as %v\n", notation, rule)
+	}
+	rule, err := gol.ParseRule("square b3/s23")
+	util.Check(err)
+	assert(t, rule == gol.Conway, "square b3/s23 is %v, expected Conway\n", rule)
+	assert(t, gol.Rule{}.String() == gol.ConwayRule, "The zero Rule is %v, expected %v\n", gol.Rule{}, gol.ConwayRule)
+
+	for _, notation := range []string{"hex B7/S2", "oct B3/S23", "B3S23", "S23/B3", ""} {
+		_, err := gol.ParseRule(notation)
+		assert(t, err != nil, "Parsed %q without an error\n", notation)
+	}
+}
+
+// TestHexGrid checks a turn of two cells worked out by hand on hex and triangle grids, where they only
+// share the neighbours the offset layout gives them.
+func TestHexGrid(t *testing.T) {
+	pair := []util.Cell{{X: 2, Y: 2}, {X: 3, Y: 2}}
+
+	hex, err := gol.ParseRule("hex B2/S")
+	util.Check(err)
+	p := gol.Params{Turns: 1, Threads: 2, ImageWidth: 8, ImageHeight: 8, Rule: hex}
+	final, saved := runCells(t, p, pair, nil)
+	expected := []util.Cell{{X: 2, Y: 1}, {X: 2, Y: 3}}
+	assert(t, reflect.DeepEqual(final, expected), "Hex pair became %v, expected %v\n", final, expected)
+
+	data, err := os.ReadFile(saved)
+	util.Check(err)
+	assert(t, strings.Contains(string(data), "# rule hex B2/S\n"), "Snapshot %v does not record its rule\n", saved)
+	world, rule, err := gol.ReadPatternRule(saved)
+	util.Check(err)
+	assert(t, world[1][2] != 0 && world[3][2] != 0, "Snapshot %v does not hold the cells alive at the end\n", saved)
+	assert(t, rule == hex, "Snapshot %v was read back under rule %v, expected %v\n", saved, rule, hex)
+
+	// (2, 2) points up and (3, 2) points down, so they share a side and eight neighbours.
+	tri, err := gol.ParseRule("tri B2/S")
+	util.Check(err)
+	p.Rule = tri
+	final, _ = runCells(t, p, pair, nil)
+	expected = []util.Cell{
+		{X: 1, Y: 1}, {X: 2, Y: 1}, {X: 3, Y: 1},
+		{X: 1, Y: 2}, {X: 4, Y: 2},
+		{X: 2, Y: 3}, {X: 3, Y: 3}, {X: 4, Y: 3},
+	}
+	assert(t, reflect.DeepEqual(final, expected), "Triangle pair became %v, expected %v\n", final, expected)
+}
+
+// TestHexUnbounded checks a hex and a triangle pattern in an unbounded world against the same pattern on a
+// board big enough that it never reaches the edges.
+func TestHexUnbounded(t *testing.T) {
+	pattern := []util.Cell{{X: 3, Y: 2}, {X: 4, Y: 2}, {X: 2, Y: 3}, {X: 4, Y: 3}, {X: 3, Y: 4}, {X: 3, Y: 5}}
+	for _, notation := range []string{"hex B2/S34", "tri B4/S345", "B36/S23"} {
+		rule, err := gol.ParseRule(notation)
+		util.Check(err)
+		p := gol.Params{Turns: 30, Threads: 2, ImageWidth: 8, ImageHeight: 8, Rule: rule, Unbounded: true}
+		unbounded, _ := runCells(t, p, pattern, nil)
+		// An even shift keeps hex rows and triangles pointing the same way.
+		p.ImageWidth, p.ImageHeight, p.Unbounded = 128, 128, false
+		bounded, _ := runCells(t, p, shift(pattern, 60, 60), nil)
+		bounded = shift(bounded, -60, -60)
+		assert(t, len(bounded) > 0, "%v died out, so it cannot be compared\n", notation)
+		assert(t, reflect.DeepEqual(unbounded, bounded), "Unbounded %v has %v alive cells after 30 turns, bounded has %v\n",
+			notation, len(unbounded), len(bounded))
+	}
+}
+
+// TestConfigRule checks the rule of a config file reaches gol.Params, and that a hex world must wrap evenly.
+func TestConfigRule(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hex.json")
+	util.Check(os.WriteFile(path, []byte(`{"run": {"width": 64, "height": 64}, "rule": "hex B2/S34"}`), 0666))
+	c, err := config.Load(path)
+	util.Check(err)
+	p, err := c.Params()
+	util.Check(err)
+	assert(t, p.Rule.Grid == gol.HexGrid && p.Rule.String() == "hex B2/S34", "Rule is %v, expected hex B2/S34\n", p.Rule)
+
+	util.Check(os.WriteFile(path, []byte(`{"run": {"width": 64, "height": 63}, "rule": "hex B2/S34"}`), 0666))
+	_, err = config.Load(path)
+	assert(t, err != nil, "Loaded a hex world with an odd height without an error\n")
+}
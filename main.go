@@ -1,52 +1,154 @@
 package main
 
 import (
-	"flag"
+	"bufio"
 	"fmt"
 	"os"
 	"os/signal"
 	"runtime"
+	"strings"
 	"syscall"
 
+	"uk.ac.bris.cs/gameoflife/census"
 	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/metrics"
 	"uk.ac.bris.cs/gameoflife/sdl"
+	"uk.ac.bris.cs/gameoflife/term"
+	"uk.ac.bris.cs/gameoflife/util"
+	"uk.ac.bris.cs/gameoflife/web"
 )
 
 // main is the function called when starting Game of Life with 'go run .'
+// The first argument picks a subcommand; without one the simulation is run, e.g. 'go run . -t 4'.
 func main() {
 	runtime.LockOSThread()
-	var params gol.Params
-
-	flag.IntVar(
-		&params.Threads,
-		"t",
-		8,
-		"Specify the number of server threads to use. Defaults to 8.")
-
-	flag.IntVar(
-		&params.ImageWidth,
-		"w",
-		512,
-		"Specify the width of the image. Defaults to 512.")
-
-	flag.IntVar(
-		&params.ImageHeight,
-		"h",
-		512,
-		"Specify the height of the image. Defaults to 512.")
-
-	flag.IntVar(
-		&params.Turns,
-		"turns",
-		10000000000,
-		"Specify the number of turns to process. Defaults to 10000000000.")
-
-	headless := flag.Bool(
-		"headless",
-		false,
-		"Disable the SDL window for running in a headless environment.")
-
-	flag.Parse()
+	args := os.Args[1:]
+	name := "run"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+	if name == "help" {
+		usage()
+		return
+	}
+	for _, command := range subcommands {
+		if command.name == name {
+			command.run(args)
+			return
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", name)
+	usage()
+	os.Exit(2)
+}
+
+// runCommand runs the simulation and shows it in a viewer.
+func runCommand(args []string) {
+	flags := newFlagSet("run")
+	cfg := loadConfig(args)
+	params, err := cfg.Params()
+	util.Check(err)
+	paramsFlags(flags, &params)
+
+	flags.IntVar(
+		&params.StatsEvery,
+		"stats",
+		params.StatsEvery,
+		"Send population statistics every this many turns. 0 turns them off.")
+
+	flags.IntVar(
+		&params.TrackEvery,
+		"track",
+		params.TrackEvery,
+		"Look for gliders and other spaceships every this many turns and report where they are heading. 0 turns it off.")
+
+	flags.IntVar(
+		&params.SnapshotEvery,
+		"snapturns",
+		params.SnapshotEvery,
+		"Save the world every this many turns. 0 turns it off.")
+
+	flags.DurationVar(
+		&params.SnapshotInterval,
+		"snapinterval",
+		params.SnapshotInterval,
+		"Save the world this often, e.g. 30s. 0 turns it off.")
+
+	flags.Var(
+		(*breakpointFlags)(&params.Breakpoints),
+		"break",
+		"Pause when a condition starts to hold: turn=N, population<N or cell=X,Y. May be repeated.")
+
+	flags.Var(
+		(*engineFlag)(&params.Engine),
+		"engine",
+		"How to compute turns: dense, sparse, or auto to use the sparse engine while few cells are alive.")
+
+	flags.Var(
+		(*ruleFlag)(&params.Rule),
+		"rule",
+		"Birth/survival rule, optionally on a hex or tri grid, e.g. B36/S23 or \"hex B2/S34\".")
+
+	flags.BoolVar(
+		&params.Unbounded,
+		"unbounded",
+		params.Unbounded,
+		"Let the world grow as patterns expand instead of wrapping at its edges. The SDL window follows the pattern.")
+
+	statsFile := flags.String(
+		"statscsv",
+		cfg.Sinks.StatsCSV,
+		"Write population statistics to this CSV file. Use with -stats.")
+
+	viewer := cfg.Viewer
+	viewerFlags(flags, &viewer)
+
+	logFile := flags.String(
+		"log",
+		cfg.Sinks.Log,
+		"Record every event to this file as JSON lines.")
+
+	replayFile := flags.String(
+		"replay",
+		"",
+		"Replay events from a log written with -log instead of running the simulation. The board size and rule come from the log.")
+
+	speed := flags.Float64(
+		"speed",
+		1,
+		"Replay speed relative to the original run. 0 replays as fast as possible.")
+
+	printCensus := flags.Bool(
+		"census",
+		cfg.Sinks.Census,
+		"Print a census of still lifes, oscillators and spaceships in the final world.")
+
+	metricsAddr := flags.String(
+		"metrics",
+		cfg.Sinks.Metrics,
+		"Serve Prometheus metrics at /metrics on this address (e.g. :9100).")
+
+	flags.Parse(args)
+	var replay *os.File
+	var replayEvents *bufio.Reader
+	if *replayFile != "" {
+		var err error
+		replay, err = os.Open(*replayFile)
+		util.Check(err)
+		replayEvents = bufio.NewReader(replay)
+		header, err := gol.ReadLogHeader(replayEvents)
+		util.Check(err)
+		params, err = header.Params(params)
+		util.Check(err)
+	}
+	if params.Unbounded && (params.StatsEvery > 0 || params.TrackEvery > 0 || *printCensus || viewer == "term" || webViewer(viewer) != "") {
+		util.Check(fmt.Errorf("-unbounded cannot be used with -stats, -track, -census, -term or -web"))
+	}
+	if params.Rule != gol.Conway && (params.TrackEvery > 0 || *printCensus) {
+		util.Check(fmt.Errorf("-track and -census only recognise patterns of %v", gol.ConwayRule))
+	}
+	util.Check(params.CheckRule())
+	util.Check(params.CheckBreakpoints())
 
 	fmt.Printf("%-10v %v\n", "Threads", params.Threads)
 	fmt.Printf("%-10v %v\n", "Width", params.ImageWidth)
@@ -59,12 +161,127 @@ func main() {
 	// not that the "keyPresses" channel is shared between 2 goroutines
 	// events channel is also shared
 	go sigterm(keyPresses)
-	go gol.Run(params, events, keyPresses)
-	if !(*headless) {
-		sdl.Run(params, events, keyPresses)
+	if replay != nil {
+		go func() {
+			defer replay.Close()
+			util.Check(gol.ReplayEvents(replayEvents, events, keyPresses, *speed))
+		}()
+	} else {
+		go gol.Run(params, events, keyPresses)
+	}
+
+	// The bus lets the viewer and the event log each read every event at their own pace.
+	// A viewer that falls behind gets merged cell flips and skips frames rather than stalling the engine.
+	bus := gol.NewBus()
+	viewerEvents := bus.Subscribe(gol.Coalesce, 1000).Events()
+	logDone := make(chan bool, 1)
+	if *logFile != "" {
+		f, err := os.Create(*logFile)
+		util.Check(err)
+		logEvents := bus.Subscribe(gol.Block, 1000).Events()
+		go func() {
+			util.Check(gol.LogEvents(params, logEvents, nil, f))
+			util.Check(f.Close())
+			logDone <- true
+		}()
 	} else {
-		sdl.RunHeadless(events)
+		logDone <- true
+	}
+	statsDone := make(chan bool, 1)
+	if *statsFile != "" {
+		f, err := os.Create(*statsFile)
+		util.Check(err)
+		statsEvents := bus.Subscribe(gol.Block, 100, gol.PopulationStats{}).Events()
+		go func() {
+			util.Check(gol.WriteStatsCSV(statsEvents, f))
+			util.Check(f.Close())
+			statsDone <- true
+		}()
+	} else {
+		statsDone <- true
+	}
+	censusDone := make(chan bool, 1)
+	if *printCensus {
+		finalEvents := bus.Subscribe(gol.Block, 1, gol.FinalTurnComplete{}).Events()
+		go func() {
+			for event := range finalEvents {
+				final := event.(gol.FinalTurnComplete)
+				fmt.Printf("Census at turn %v\n%v", final.CompletedTurns, census.Report(census.Count(final.Alive, params.ImageWidth, params.ImageHeight)))
+			}
+			censusDone <- true
+		}()
+	} else {
+		censusDone <- true
+	}
+	if *metricsAddr != "" {
+		metrics.Serve(*metricsAddr)
+		go gol.RecordMetrics(bus.Subscribe(gol.Coalesce, 100, gol.TurnComplete{}, gol.AliveCellsCount{}).Events())
+	}
+	go bus.Run(events)
+
+	switch viewer {
+	case "headless":
+		sdl.RunHeadless(viewerEvents)
+	case "term":
+		term.Run(params, viewerEvents, keyPresses)
+	case "sdl":
+		sdl.Run(params, viewerEvents, keyPresses)
+	default:
+		web.Run(params, viewerEvents, keyPresses, viewer)
+	}
+	<-logDone
+	<-statsDone
+	<-censusDone
+}
+
+// breakpointFlags collects every -break flag.
+type breakpointFlags []gol.Breakpoint
+
+func (b *breakpointFlags) String() string {
+	if b == nil {
+		return ""
+	}
+	var conditions []string
+	for _, breakpoint := range *b {
+		conditions = append(conditions, breakpoint.String())
+	}
+	return strings.Join(conditions, " ")
+}
+
+func (b *breakpointFlags) Set(value string) error {
+	breakpoint, err := gol.ParseBreakpoint(value)
+	if err != nil {
+		return err
+	}
+	*b = append(*b, breakpoint)
+	return nil
+}
+
+// engineFlag reads a gol.EngineKind by name.
+type engineFlag gol.EngineKind
+
+func (e *engineFlag) String() string {
+	return gol.EngineKind(*e).String()
+}
+
+func (e *engineFlag) Set(value string) error {
+	return (*gol.EngineKind)(e).UnmarshalText([]byte(value))
+}
+
+// ruleFlag reads a gol.Rule in B/S notation.
+type ruleFlag gol.Rule
+
+func (r *ruleFlag) String() string {
+	return gol.Rule(*r).String()
+}
+
+func (r *ruleFlag) Set(value string) error {
+	rule, err := gol.ParseRule(value)
+	if err != nil {
+		return err
 	}
+	*r = ruleFlag(rule)
+	return nil
 }
 
 func sigterm(keyPresses chan<- rune) {
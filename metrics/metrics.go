@@ -0,0 +1,238 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// This package exposes counters, gauges and histograms in the Prometheus text format
+// (https://prometheus.io/docs/instrumenting/exposition_formats/) using only the standard library.
+
+// DefaultBuckets are histogram upper bounds in seconds, from 100µs to 10s.
+var DefaultBuckets = []float64{0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5, 10}
+
+// Registry holds a set of metrics and writes them out when scraped.
+type Registry struct {
+	mutex    sync.Mutex
+	families []*family
+}
+
+// Default is the registry used by the package-level constructors and by Serve.
+var Default = &Registry{}
+
+type family struct {
+	name    string
+	help    string
+	kind    string
+	labels  []string
+	buckets []float64
+	mutex   sync.Mutex
+	series  map[string]*series
+}
+
+type series struct {
+	labelValues []string
+	value       float64
+	counts      []uint64
+	count       uint64
+}
+
+// Counter is a value that only goes up, such as the number of turns completed.
+type Counter struct{ f *family }
+
+// Gauge is a value that can go up and down, such as the number of alive cells.
+type Gauge struct{ f *family }
+
+// Histogram counts observations, such as durations, in cumulative buckets.
+type Histogram struct{ f *family }
+
+func (r *Registry) add(name, help, kind string, labels []string, buckets []float64) *family {
+	f := &family{
+		name:    name,
+		help:    help,
+		kind:    kind,
+		labels:  labels,
+		buckets: buckets,
+		series:  make(map[string]*series),
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.families = append(r.families, f)
+	return f
+}
+
+// NewCounter registers a counter. Values for labels must be passed, in order, to Inc and Add.
+func (r *Registry) NewCounter(name, help string, labels ...string) *Counter {
+	return &Counter{r.add(name, help, "counter", labels, nil)}
+}
+
+// NewGauge registers a gauge. Values for labels must be passed, in order, to Set and Add.
+func (r *Registry) NewGauge(name, help string, labels ...string) *Gauge {
+	return &Gauge{r.add(name, help, "gauge", labels, nil)}
+}
+
+// NewHistogram registers a histogram with the given bucket upper bounds, or DefaultBuckets if nil.
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+	return &Histogram{r.add(name, help, "histogram", labels, buckets)}
+}
+
+func NewCounter(name, help string, labels ...string) *Counter {
+	return Default.NewCounter(name, help, labels...)
+}
+
+func NewGauge(name, help string, labels ...string) *Gauge {
+	return Default.NewGauge(name, help, labels...)
+}
+
+func NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	return Default.NewHistogram(name, help, buckets, labels...)
+}
+
+// with returns the series for labelValues, creating it on first use. The caller must hold f.mutex.
+func (f *family) with(labelValues []string) *series {
+	if len(labelValues) != len(f.labels) {
+		panic(fmt.Sprintf("metric %v expects %v label values, got %v", f.name, len(f.labels), len(labelValues)))
+	}
+	key := strings.Join(labelValues, "\xff")
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{labelValues: append([]string(nil), labelValues...)}
+		if f.buckets != nil {
+			s.counts = make([]uint64, len(f.buckets))
+		}
+		f.series[key] = s
+	}
+	return s
+}
+
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+func (c *Counter) Add(delta float64, labelValues ...string) {
+	if delta < 0 {
+		panic("counter cannot decrease")
+	}
+	c.f.mutex.Lock()
+	c.f.with(labelValues).value += delta
+	c.f.mutex.Unlock()
+}
+
+func (g *Gauge) Set(value float64, labelValues ...string) {
+	g.f.mutex.Lock()
+	g.f.with(labelValues).value = value
+	g.f.mutex.Unlock()
+}
+
+func (g *Gauge) Add(delta float64, labelValues ...string) {
+	g.f.mutex.Lock()
+	g.f.with(labelValues).value += delta
+	g.f.mutex.Unlock()
+}
+
+func (h *Histogram) Observe(value float64, labelValues ...string) {
+	h.f.mutex.Lock()
+	defer h.f.mutex.Unlock()
+	s := h.f.with(labelValues)
+	for i, bound := range h.f.buckets {
+		if value <= bound {
+			s.counts[i]++
+		}
+	}
+	s.count++
+	s.value += value
+}
+
+// WriteTo writes every metric in the Prometheus text format.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mutex.Lock()
+	families := append([]*family(nil), r.families...)
+	r.mutex.Unlock()
+
+	var out strings.Builder
+	for _, f := range families {
+		f.write(&out)
+	}
+	n, err := io.WriteString(w, out.String())
+	return int64(n), err
+}
+
+func (f *family) write(out *strings.Builder) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	fmt.Fprintf(out, "# HELP %v %v\n", f.name, f.help)
+	fmt.Fprintf(out, "# TYPE %v %v\n", f.name, f.kind)
+	keys := make([]string, 0, len(f.series))
+	for key := range f.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		s := f.series[key]
+		if f.kind != "histogram" {
+			fmt.Fprintf(out, "%v%v %v\n", f.name, f.labelString(s.labelValues, "", ""), formatValue(s.value))
+			continue
+		}
+		for i, bound := range f.buckets {
+			fmt.Fprintf(out, "%v_bucket%v %v\n", f.name, f.labelString(s.labelValues, "le", formatValue(bound)), s.counts[i])
+		}
+		fmt.Fprintf(out, "%v_bucket%v %v\n", f.name, f.labelString(s.labelValues, "le", "+Inf"), s.count)
+		fmt.Fprintf(out, "%v_sum%v %v\n", f.name, f.labelString(s.labelValues, "", ""), formatValue(s.value))
+		fmt.Fprintf(out, "%v_count%v %v\n", f.name, f.labelString(s.labelValues, "", ""), s.count)
+	}
+}
+
+// labelString formats label pairs as {a="1",b="2"}, with an optional extra pair such as le for buckets.
+func (f *family) labelString(values []string, extraName, extraValue string) string {
+	var pairs []string
+	for i, name := range f.labels {
+		pairs = append(pairs, fmt.Sprintf("%v=%q", name, values[i]))
+	}
+	if extraName != "" {
+		pairs = append(pairs, fmt.Sprintf("%v=%q", extraName, extraValue))
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatValue(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return fmt.Sprint(value)
+}
+
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	_, _ = r.WriteTo(w)
+}
+
+// Serve exposes the Default registry at /metrics on addr in the background.
+func Serve(addr string) {
+	listener, err := net.Listen("tcp", addr)
+	util.Check(err)
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Default)
+	fmt.Printf("Metrics available at http://%v/metrics\n", listener.Addr())
+	go func() {
+		_ = http.Serve(listener, mux)
+	}()
+}
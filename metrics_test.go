@@ -0,0 +1,45 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/metrics"
+)
+
+// TestMetrics checks the Prometheus text format written by a metrics.Registry.
+func TestMetrics(t *testing.T) {
+	registry := &metrics.Registry{}
+	calls := registry.NewCounter("calls_total", "Calls made.", "method")
+	alive := registry.NewGauge("alive_cells", "Alive cells.")
+	latency := registry.NewHistogram("step_seconds", "Step time.", []float64{0.1, 1}, "worker")
+
+	calls.Inc("Update")
+	calls.Add(2, "Update")
+	calls.Inc("Pause")
+	alive.Set(42)
+	latency.Observe(0.05, "0")
+	latency.Observe(0.5, "0")
+
+	var out strings.Builder
+	_, err := registry.WriteTo(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `# HELP calls_total Calls made.
+# TYPE calls_total counter
+calls_total{method="Pause"} 1
+calls_total{method="Update"} 3
+# HELP alive_cells Alive cells.
+# TYPE alive_cells gauge
+alive_cells 42
+# HELP step_seconds Step time.
+# TYPE step_seconds histogram
+step_seconds_bucket{worker="0",le="0.1"} 1
+step_seconds_bucket{worker="0",le="1"} 2
+step_seconds_bucket{worker="0",le="+Inf"} 2
+step_seconds_sum{worker="0"} 0.55
+step_seconds_count{worker="0"} 2
+`
+	assert(t, out.String() == expected, "Metrics output was\n%v\nexpected\n%v", out.String(), expected)
+}
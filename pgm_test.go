@@ -2,9 +2,12 @@ package main
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
 )
 
 // Pgm tests 16x16, 64x64 and 512x512 image output files on 0, 1 and 100 turns using 1-16 server threads.
@@ -44,3 +47,13 @@ func TestPgm(t *testing.T) {
 		}
 	}
 }
+
+// TestPgmHeader checks that images cut short in or just after the header are an error rather than a panic.
+func TestPgmHeader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "short.pgm")
+	for _, data := range []string{"P5 1 1 255", "P5 1 1 255\n", "P5 2 2 255\n\xff", "P5 1 1"} {
+		util.Check(os.WriteFile(path, []byte(data), 0666))
+		_, err := gol.ReadPgm(path)
+		assert(t, err != nil, "Read %q without an error\n", data)
+	}
+}
@@ -0,0 +1,183 @@
+package sdl
+
+import "math"
+
+// ColourMode selects how live and recently changed cells are coloured.
+type ColourMode int
+
+const (
+	// PlainColour draws every live cell white.
+	PlainColour ColourMode = iota
+	// AgeColour colours live cells by the number of turns they have been alive.
+	AgeColour
+	// HeatColour colours cells by how often they have flipped recently.
+	HeatColour
+)
+
+const (
+	heatDecay   = 0.9 // fraction of a cell's heat that survives each turn
+	heatHistory = 64  // turns after which a flip no longer contributes any heat
+	heatScale   = 3.0 // heat at which a cell reaches the hottest colour
+)
+
+// heatPowers[n] is heatDecay^n, so decaying a cell is a lookup rather than a call to math.Pow.
+var heatPowers = func() [heatHistory]float32 {
+	var powers [heatHistory]float32
+	for n := range powers {
+		powers[n] = float32(math.Pow(heatDecay, float64(n)))
+	}
+	return powers
+}()
+
+type colour struct {
+	r, g, b uint8
+}
+
+var (
+	agePalette  = []colour{{0xFF, 0xF0, 0x40}, {0xFF, 0x60, 0x20}, {0xC0, 0x20, 0x90}, {0x30, 0x40, 0xD0}}
+	heatPalette = []colour{{0x00, 0x00, 0x00}, {0x90, 0x00, 0x00}, {0xFF, 0x40, 0x00}, {0xFF, 0xE0, 0x40}, {0xFF, 0xFF, 0xFF}}
+	stableLive  = colour{0x30, 0x50, 0xA0}
+	black       = colour{0x00, 0x00, 0x00}
+)
+
+func (mode ColourMode) String() string {
+	switch mode {
+	case PlainColour:
+		return "Plain"
+	case AgeColour:
+		return "Age"
+	case HeatColour:
+		return "Heat"
+	default:
+		return "Incorrect ColourMode"
+	}
+}
+
+// cellHistory remembers enough about each cell to colour it by age or activity.
+// born and lastFlip are turn numbers; heat is only correct as of lastFlip and decays from there.
+type cellHistory struct {
+	turn     int
+	alive    []bool
+	born     []int32
+	lastFlip []int32
+	heat     []float32
+}
+
+// SetColourMode changes how cells are coloured.
+// History is only kept once a colour mode has been used, so ages count from the first switch.
+func (w *Window) SetColourMode(mode ColourMode) {
+	if mode != PlainColour && w.history == nil {
+		w.startHistory()
+	}
+	w.colourMode = mode
+	if mode == PlainColour && w.history != nil {
+		for i, alive := range w.history.alive {
+			var value byte
+			if alive {
+				value = 0xFF
+			}
+			copy(w.pixels[4*i:4*i+4], []byte{value, value, value, value})
+		}
+	}
+}
+
+// CycleColourMode moves on to the next colour mode, wrapping around to PlainColour.
+func (w *Window) CycleColourMode() ColourMode {
+	w.SetColourMode((w.colourMode + 1) % (HeatColour + 1))
+	return w.colourMode
+}
+
+// SetTurn records the turn that subsequent flips belong to.
+// Ages and heat are worked out from it when the frame is rendered.
+func (w *Window) SetTurn(turn int) {
+	if w.history != nil {
+		w.history.turn = turn
+	}
+}
+
+// startHistory begins tracking cells, taking the current live cells from the pixel buffer.
+func (w *Window) startHistory() {
+	size := int(w.Width) * int(w.Height)
+	w.history = &cellHistory{
+		alive:    make([]bool, size),
+		born:     make([]int32, size),
+		lastFlip: make([]int32, size),
+		heat:     make([]float32, size),
+	}
+	for i := range w.history.alive {
+		w.history.alive[i] = w.pixels[4*i] == 0xFF
+	}
+}
+
+// flip updates the history of the cell at index i.
+func (h *cellHistory) flip(i int) {
+	h.alive[i] = !h.alive[i]
+	if h.alive[i] {
+		h.born[i] = int32(h.turn)
+	}
+	h.heat[i] = h.heatAt(i) + 1
+	h.lastFlip[i] = int32(h.turn)
+}
+
+// heatAt returns the heat of the cell at index i, decayed up to the current turn.
+func (h *cellHistory) heatAt(i int) float32 {
+	elapsed := h.turn - int(h.lastFlip[i])
+	if elapsed >= heatHistory {
+		return 0
+	}
+	return h.heat[i] * heatPowers[elapsed]
+}
+
+// recolour repaints every pixel from the cell history using the current colour mode.
+func (w *Window) recolour() {
+	h := w.history
+	for i, alive := range h.alive {
+		switch w.colourMode {
+		case AgeColour:
+			age := float64(h.turn - int(h.born[i]))
+			t := math.Min(1, math.Log2(1+age)/10)
+			if alive {
+				w.paint(i, gradient(agePalette, t))
+			} else {
+				w.paint(i, black)
+			}
+		case HeatColour:
+			t := float64(h.heatAt(i)) / heatScale
+			c := gradient(heatPalette, math.Min(1, t))
+			if alive {
+				c = colour{maxByte(c.r, stableLive.r), maxByte(c.g, stableLive.g), maxByte(c.b, stableLive.b)}
+			}
+			w.paint(i, c)
+		}
+	}
+}
+
+// paint writes c to the pixel at index i.
+func (w *Window) paint(i int, c colour) {
+	w.pixels[4*i+0] = c.b
+	w.pixels[4*i+1] = c.g
+	w.pixels[4*i+2] = c.r
+	w.pixels[4*i+3] = 0xFF
+}
+
+// gradient linearly interpolates between evenly spaced palette stops, with t in [0, 1].
+func gradient(palette []colour, t float64) colour {
+	pos := t * float64(len(palette)-1)
+	i := int(pos)
+	if i >= len(palette)-1 {
+		return palette[len(palette)-1]
+	}
+	frac := pos - float64(i)
+	lerp := func(a, b uint8) uint8 {
+		return uint8(float64(a) + (float64(b)-float64(a))*frac)
+	}
+	from, to := palette[i], palette[i+1]
+	return colour{lerp(from.r, to.r), lerp(from.g, to.g), lerp(from.b, to.b)}
+}
+
+func maxByte(a, b uint8) uint8 {
+	if a > b {
+		return a
+	}
+	return b
+}
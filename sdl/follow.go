@@ -0,0 +1,74 @@
+package sdl
+
+import (
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// follower shows an unbounded world, whose cells may be anywhere, in a window of Width x Height cells
+// starting at origin. When the pattern leaves the window, the window moves to centre it again.
+type follower struct {
+	alive  map[util.Cell]bool
+	origin util.Cell
+}
+
+func newFollower() *follower {
+	return &follower{alive: make(map[util.Cell]bool)}
+}
+
+// flip records cells that flipped and draws those inside the window.
+func (f *follower) flip(w *Window, cells []util.Cell) {
+	for _, cell := range cells {
+		if f.alive[cell] {
+			delete(f.alive, cell)
+		} else {
+			f.alive[cell] = true
+		}
+		if x, y, ok := f.toWindow(w, cell); ok {
+			w.FlipPixel(x, y)
+		}
+	}
+}
+
+func (f *follower) toWindow(w *Window, cell util.Cell) (int, int, bool) {
+	x, y := cell.X-f.origin.X, cell.Y-f.origin.Y
+	return x, y, x >= 0 && y >= 0 && x < int(w.Width) && y < int(w.Height)
+}
+
+// recentre moves the window onto the pattern if it no longer fits inside, and redraws it.
+// A pattern bigger than the window is only followed once its centre is a quarter of the window away.
+func (f *follower) recentre(w *Window) {
+	if len(f.alive) == 0 {
+		return
+	}
+	cells := make([]util.Cell, 0, len(f.alive))
+	for cell := range f.alive {
+		cells = append(cells, cell)
+	}
+	min, max := gol.Bounds(cells)
+	width, height := int(w.Width), int(w.Height)
+	centre := util.Cell{X: (min.X + max.X) / 2, Y: (min.Y + max.Y) / 2}
+	if max.X-min.X < width && max.Y-min.Y < height {
+		if min.X >= f.origin.X && min.Y >= f.origin.Y && max.X < f.origin.X+width && max.Y < f.origin.Y+height {
+			return
+		}
+	} else if abs(centre.X-f.origin.X-width/2) < width/4 && abs(centre.Y-f.origin.Y-height/2) < height/4 {
+		return
+	}
+
+	// The origin stays even so that hex rows and triangles keep the shapes they are drawn with.
+	f.origin = util.Cell{X: (centre.X - width/2) &^ 1, Y: (centre.Y - height/2) &^ 1}
+	w.ClearPixels()
+	for _, cell := range cells {
+		if x, y, ok := f.toWindow(w, cell); ok {
+			w.SetPixel(x, y)
+		}
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
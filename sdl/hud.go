@@ -0,0 +1,143 @@
+package sdl
+
+import (
+	"fmt"
+
+	"github.com/veandco/go-sdl2/sdl"
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+const (
+	hudScale   = 2  // size of one font pixel on screen
+	hudPadding = 6  // gap between the overlay box and its text
+	hudMargin  = 10 // gap between the overlay box and the window edge
+)
+
+// status collects what the overlay and window title show about the run.
+type status struct {
+	turns    int
+	alive    int
+	rate     int
+	state    gol.State
+	rule     string
+	colour   ColourMode
+	avgTurns *util.AvgTurns
+	cells    map[util.Cell]bool // the alive cells, from the flips, so alive changes every turn
+}
+
+func newStatus(rule string) *status {
+	return &status{
+		state:    gol.Paused,
+		rule:     rule,
+		avgTurns: util.NewAvgTurns(),
+		cells:    make(map[util.Cell]bool),
+	}
+}
+
+// update records anything the event says about the run.
+// It returns true if the overlay text may have changed.
+func (s *status) update(event gol.Event) bool {
+	switch e := event.(type) {
+	case gol.CellFlipped:
+		s.flip(e.Cell)
+	case gol.CellsFlipped:
+		for _, cell := range e.Cells {
+			s.flip(cell)
+		}
+	case gol.TurnComplete:
+		s.turns = e.CompletedTurns
+	case gol.AliveCellsCount:
+		s.turns = e.CompletedTurns
+		s.alive = e.CellsCount
+		s.rate = s.avgTurns.Get(e.CompletedTurns)
+	case gol.StateChange:
+		s.turns = e.CompletedTurns
+		s.state = e.NewState
+	case gol.FinalTurnComplete:
+		s.turns = e.CompletedTurns
+		s.alive = len(e.Alive)
+	default:
+		return false
+	}
+	return true
+}
+
+// flip records that a cell has changed state.
+func (s *status) flip(cell util.Cell) {
+	if s.cells[cell] {
+		delete(s.cells, cell)
+	} else {
+		s.cells[cell] = true
+	}
+	s.alive = len(s.cells)
+}
+
+func (s *status) lines() []string {
+	return []string{
+		fmt.Sprintf("Turn   %v", s.turns),
+		fmt.Sprintf("Alive  %v", s.alive),
+		fmt.Sprintf("Rate   %v turns/s", s.rate),
+		fmt.Sprintf("State  %v", s.state),
+		fmt.Sprintf("Rule   %v", s.rule),
+		fmt.Sprintf("Colour %v", s.colour),
+	}
+}
+
+func (s *status) title() string {
+	return fmt.Sprintf("GOL GUI - Turn %v - %v alive - %v", s.turns, s.alive, s.state)
+}
+
+// SetOverlay replaces the lines of text shown in the top-left corner of the window.
+func (w *Window) SetOverlay(lines []string) {
+	w.overlay = lines
+}
+
+// ToggleOverlay shows or hides the text overlay.
+func (w *Window) ToggleOverlay() {
+	w.overlayHidden = !w.overlayHidden
+}
+
+// SetTitle changes the window title, skipping the call into SDL if it is unchanged.
+func (w *Window) SetTitle(title string) {
+	if title != w.title {
+		w.title = title
+		w.window.SetTitle(title)
+	}
+}
+
+// drawOverlay draws the overlay text on a translucent box so it stays readable over live cells.
+func (w *Window) drawOverlay() {
+	if w.overlayHidden || len(w.overlay) == 0 {
+		return
+	}
+	width := int32(0)
+	for _, line := range w.overlay {
+		if lineWidth := textWidth(line, hudScale); lineWidth > width {
+			width = lineWidth
+		}
+	}
+	lineHeight := int32(glyphHeight+2) * hudScale
+	box := sdl.Rect{
+		X: hudMargin,
+		Y: hudMargin,
+		W: width + 2*hudPadding,
+		H: int32(len(w.overlay))*lineHeight + 2*hudPadding,
+	}
+
+	err := w.renderer.SetDrawBlendMode(sdl.BLENDMODE_BLEND)
+	util.Check(err)
+	err = w.renderer.SetDrawColor(0, 0, 0, 0xB0)
+	util.Check(err)
+	err = w.renderer.FillRect(&box)
+	util.Check(err)
+	err = w.renderer.SetDrawColor(0xFF, 0xFF, 0xFF, 0xFF)
+	util.Check(err)
+	for i, line := range w.overlay {
+		w.drawText(line, box.X+hudPadding, box.Y+hudPadding+int32(i)*lineHeight, hudScale)
+	}
+	err = w.renderer.SetDrawBlendMode(sdl.BLENDMODE_NONE)
+	util.Check(err)
+	err = w.renderer.SetDrawColor(0, 0, 0, 0xFF)
+	util.Check(err)
+}
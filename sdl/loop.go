@@ -11,18 +11,25 @@ import (
 const FPS = 60
 
 func Run(p gol.Params, events <-chan gol.Event, keyPresses chan<- rune) {
-	w := NewWindow(int32(p.ImageWidth), int32(p.ImageHeight))
+	w := NewGridWindow(int32(p.ImageWidth), int32(p.ImageHeight), p.Rule.Grid)
 	defer w.Destroy()
 	dirty := false
 	refreshTicker := time.NewTicker(time.Second / time.Duration(FPS))
-	avgTurns := util.NewAvgTurns()
+	status := newStatus(p.Rule.String())
+	var follow *follower
+	if p.Unbounded {
+		follow = newFollower()
+	}
 
 sdl:
 	for {
 		select {
 		case <-refreshTicker.C:
-			event := w.PollEvent()
-			if event != nil {
+			for event := w.PollEvent(); event != nil; event = w.PollEvent() {
+				if w.HandleViewEvent(event) {
+					dirty = true
+					continue
+				}
 				switch e := event.(type) {
 				case *sdl.QuitEvent:
 					keyPresses <- 'q'
@@ -38,10 +45,35 @@ sdl:
 						keyPresses <- 'q'
 					case sdl.K_k:
 						keyPresses <- 'k'
+					case sdl.K_f:
+						w.FitToWindow()
+						dirty = true
+					case sdl.K_g:
+						w.ToggleGrid()
+						dirty = true
+					case sdl.K_h:
+						w.ToggleOverlay()
+						dirty = true
+					case sdl.K_t:
+						w.ToggleTrajectories()
+						dirty = true
+					case sdl.K_c:
+						status.colour = w.CycleColourMode()
+						w.SetOverlay(status.lines())
+						dirty = true
+					case sdl.K_EQUALS:
+						w.ZoomCentre(zoomStep)
+						dirty = true
+					case sdl.K_MINUS:
+						w.ZoomCentre(1 / zoomStep)
+						dirty = true
 					}
 				}
 			}
 			if dirty {
+				if follow != nil {
+					follow.recentre(w)
+				}
 				w.RenderFrame()
 				dirty = false
 			}
@@ -50,23 +82,36 @@ sdl:
 			if !ok {
 				break sdl
 			}
+			if status.update(event) {
+				w.SetOverlay(status.lines())
+				w.SetTitle(status.title())
+				dirty = true
+			}
 			switch e := event.(type) {
 			case gol.CellFlipped:
-				w.FlipPixel(e.Cell.X, e.Cell.Y)
+				w.SetTurn(e.CompletedTurns)
+				if follow != nil {
+					follow.flip(w, []util.Cell{e.Cell})
+				} else {
+					w.FlipPixel(e.Cell.X, e.Cell.Y)
+				}
 			case gol.CellsFlipped:
-				for _, cell := range e.Cells {
-					w.FlipPixel(cell.X, cell.Y) 
+				w.SetTurn(e.CompletedTurns)
+				if follow != nil {
+					follow.flip(w, e.Cells)
+				} else {
+					for _, cell := range e.Cells {
+						w.FlipPixel(cell.X, cell.Y) 
+					}
 				}
+				dirty = true
 			case gol.TurnComplete:
+				w.SetTurn(e.CompletedTurns)
+				dirty = true
+			case gol.SpaceshipSeen:
+				w.AddSighting(e)
 				dirty = true
-			case gol.AliveCellsCount:
-				fmt.Printf("Completed Turns %-8v %-20v Avg%+5v turns/sec\n", event.GetCompletedTurns(), event, avgTurns.Get(event.GetCompletedTurns()))
-			case gol.FinalTurnComplete:
-				fmt.Printf("Completed Turns %-8v %v\n", event.GetCompletedTurns(), event)
-			case gol.ImageOutputComplete:
-				fmt.Printf("Completed Turns %-8v %v\n", event.GetCompletedTurns(), event)
 			case gol.StateChange:
-				fmt.Printf("Completed Turns %-8v %v\n", event.GetCompletedTurns(), event)
 				if e.NewState == gol.Quitting {
 					break sdl
 				}
@@ -83,7 +128,9 @@ func RunHeadless(events <-chan gol.Event) {
 			fmt.Printf("Completed Turns %-8v %-20v Avg%+5v turns/sec\n", event.GetCompletedTurns(), event, avgTurns.Get(event.GetCompletedTurns()))
 		case gol.FinalTurnComplete:
 			fmt.Printf("Completed Turns %-8v %v\n", event.GetCompletedTurns(), "Final Turn Complete")
-		case gol.ImageOutputComplete:
+		case gol.ImageOutputComplete, gol.BreakpointHit:
+			fmt.Printf("Completed Turns %-8v %v\n", event.GetCompletedTurns(), event)
+		case gol.PopulationStats, gol.SpaceshipSeen:
 			fmt.Printf("Completed Turns %-8v %v\n", event.GetCompletedTurns(), event)
 		case gol.StateChange:
 			fmt.Printf("Completed Turns %-8v %v\n", event.GetCompletedTurns(), event)
@@ -0,0 +1,123 @@
+package sdl
+
+import (
+	"math"
+	"unsafe"
+
+	"github.com/veandco/go-sdl2/sdl"
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// shapePixels is roughly the most texture pixels a hex or triangular board is drawn with, though cells are
+// always at least 4 pixels across so that every triangle has some.
+const shapePixels = 4 << 20
+
+// cellShape draws the cells of a hex or triangular grid. Each pixel of its texture belongs to one cell, or to
+// none outside the board, and is copied from that cell's pixel in the window every frame.
+type cellShape struct {
+	texture       *sdl.Texture
+	width, height int32   // size of the texture in pixels
+	scale         float64 // texture pixels across one cell
+	cells         []int32 // the cell each texture pixel shows, or -1
+	pixels        []byte
+}
+
+// newCellShape maps out a width x height board of grid. Its texture is created by createTexture.
+func newCellShape(grid gol.Grid, width, height int32) *cellShape {
+	s := &cellShape{scale: math.Max(4, math.Min(maxZoom, math.Floor(math.Sqrt(shapePixels/(float64(width)*float64(height))))))}
+	k := s.scale
+	if grid == gol.HexGrid {
+		s.mapHexes(width, height, k)
+	} else {
+		s.mapTriangles(width, height, k)
+	}
+	s.pixels = make([]byte, len(s.cells)*4)
+	return s
+}
+
+func (s *cellShape) createTexture(renderer *sdl.Renderer) {
+	texture, err := renderer.CreateTexture(sdl.PIXELFORMAT_ARGB8888, sdl.TEXTUREACCESS_STATIC, s.width, s.height)
+	util.Check(err)
+	s.texture = texture
+}
+
+// mapHexes lays out pointy-topped hexes k pixels across, with odd rows shifted right by half a hex.
+func (s *cellShape) mapHexes(width, height int32, k float64) {
+	radius := k / math.Sqrt(3)
+	pitch := 1.5 * radius
+	s.width = int32(math.Ceil((float64(width) + 0.5) * k))
+	s.height = int32(math.Ceil(float64(height-1)*pitch + 2*radius))
+	s.cells = make([]int32, s.width*s.height)
+	for py := int32(0); py < s.height; py++ {
+		for px := int32(0); px < s.width; px++ {
+			cx, cy := float64(px)+0.5, float64(py)+0.5
+			s.cells[py*s.width+px] = -1
+			row := int32(math.Floor((cy - radius) / pitch))
+			for y := row; y <= row+1; y++ {
+				if y < 0 || y >= height {
+					continue
+				}
+				shift := 0.5 * float64(y&1)
+				x := int32(math.Floor(cx/k - shift))
+				if x < 0 || x >= width {
+					continue
+				}
+				dx := math.Abs(cx - (float64(x)+0.5+shift)*k)
+				dy := math.Abs(cy - (float64(y)*pitch + radius))
+				if dx <= k/2 && dy <= radius-dx/math.Sqrt(3) {
+					s.cells[py*s.width+px] = y*width + x
+					break
+				}
+			}
+		}
+	}
+}
+
+// mapTriangles lays out triangles with bases k pixels wide, each overlapping its neighbours in the row by half.
+// (x, y) points up when x+y is even.
+func (s *cellShape) mapTriangles(width, height int32, k float64) {
+	rowHeight := k * math.Sqrt(3) / 2
+	s.width = int32(math.Ceil(float64(width+1) * k / 2))
+	s.height = int32(math.Ceil(float64(height) * rowHeight))
+	s.cells = make([]int32, s.width*s.height)
+	for py := int32(0); py < s.height; py++ {
+		y := int32(float64(py) / rowHeight)
+		depth := (float64(py) + 0.5 - float64(y)*rowHeight) / rowHeight
+		for px := int32(0); px < s.width; px++ {
+			s.cells[py*s.width+px] = -1
+			u := (float64(px) + 0.5) / (k / 2)
+			for x := int32(u) - 1; x <= int32(u); x++ {
+				if y >= height || x < 0 || x >= width {
+					continue
+				}
+				// How far from the middle of the triangle's span the pixel may be at this depth.
+				half := 1 - depth
+				if (x+y)%2 == 0 {
+					half = depth
+				}
+				if math.Abs(u-float64(x+1)) <= half {
+					s.cells[py*s.width+px] = y*width + x
+					break
+				}
+			}
+		}
+	}
+}
+
+// boardSize is the size of the board in cells.
+func (s *cellShape) boardSize() (float64, float64) {
+	return float64(s.width) / s.scale, float64(s.height) / s.scale
+}
+
+// update copies the colour of each cell from pixels to the texture.
+func (s *cellShape) update(pixels []byte) {
+	for i, cell := range s.cells {
+		if cell < 0 {
+			continue
+		}
+		copy(s.pixels[4*i:4*i+4], pixels[4*cell:4*cell+4])
+	}
+	err := s.texture.Update(nil, unsafe.Pointer(&s.pixels[0]), int(s.width*4))
+	util.Check(err)
+}
@@ -0,0 +1,96 @@
+package sdl
+
+import (
+	"math"
+
+	"github.com/veandco/go-sdl2/sdl"
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+const (
+	trajectoryLength = 256 // most sightings remembered for each spaceship
+	trajectoryExpiry = 64  // turns after its last sighting that a trajectory is forgotten
+)
+
+// trajectory is the path of one spaceship, as sightings in board coordinates.
+type trajectory struct {
+	name     string
+	lastSeen int
+	xs, ys   []float64
+}
+
+// AddSighting extends the trajectory of the spaceship in a SpaceshipSeen event.
+// Trajectories of spaceships that have not been seen for a while are dropped.
+func (w *Window) AddSighting(seen gol.SpaceshipSeen) {
+	if w.trajectories == nil {
+		w.trajectories = make(map[int]*trajectory)
+	}
+	t, ok := w.trajectories[seen.ID]
+	if !ok {
+		t = &trajectory{name: seen.Name}
+		w.trajectories[seen.ID] = t
+	}
+	t.lastSeen = seen.CompletedTurns
+	t.xs = append(t.xs, seen.X)
+	t.ys = append(t.ys, seen.Y)
+	if len(t.xs) > trajectoryLength {
+		t.xs = t.xs[1:]
+		t.ys = t.ys[1:]
+	}
+	for id, other := range w.trajectories {
+		if seen.CompletedTurns-other.lastSeen > trajectoryExpiry {
+			delete(w.trajectories, id)
+		}
+	}
+}
+
+// ToggleTrajectories shows or hides spaceship trajectories.
+func (w *Window) ToggleTrajectories() {
+	w.trajectoriesHidden = !w.trajectoriesHidden
+}
+
+// drawTrajectories draws each spaceship's path and a box around where it was last seen.
+// Steps that wrap around the edge of the board are left out rather than drawn across it.
+func (w *Window) drawTrajectories() {
+	if w.trajectoriesHidden || len(w.trajectories) == 0 {
+		return
+	}
+	toScreen := func(x, y float64) (int32, int32) {
+		return int32(math.Round((x - w.view.x) * w.view.scale)), int32(math.Round((y - w.view.y) * w.view.scale))
+	}
+	for _, t := range w.trajectories {
+		c := trajectoryColour(t.name)
+		err := w.renderer.SetDrawColor(c.r, c.g, c.b, 0xFF)
+		util.Check(err)
+		for i := 1; i < len(t.xs); i++ {
+			if math.Abs(t.xs[i]-t.xs[i-1]) > float64(w.Width)/2 || math.Abs(t.ys[i]-t.ys[i-1]) > float64(w.Height)/2 {
+				continue
+			}
+			x1, y1 := toScreen(t.xs[i-1], t.ys[i-1])
+			x2, y2 := toScreen(t.xs[i], t.ys[i])
+			err = w.renderer.DrawLine(x1, y1, x2, y2)
+			util.Check(err)
+		}
+		x, y := toScreen(t.xs[len(t.xs)-1], t.ys[len(t.ys)-1])
+		half := int32(math.Max(3, 2*w.view.scale))
+		err = w.renderer.DrawRect(&sdl.Rect{X: x - half, Y: y - half, W: 2 * half, H: 2 * half})
+		util.Check(err)
+	}
+	err := w.renderer.SetDrawColor(0, 0, 0, 0xFF)
+	util.Check(err)
+}
+
+// trajectoryColour picks a colour for each kind of spaceship.
+func trajectoryColour(name string) colour {
+	switch name {
+	case "glider":
+		return colour{0x40, 0xFF, 0x40}
+	case "LWSS":
+		return colour{0x40, 0xC0, 0xFF}
+	case "MWSS":
+		return colour{0xFF, 0x80, 0xFF}
+	default:
+		return colour{0xFF, 0xA0, 0x40}
+	}
+}
@@ -0,0 +1,175 @@
+package sdl
+
+import (
+	"math"
+
+	"github.com/veandco/go-sdl2/sdl"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+const (
+	maxZoom  = 64   // largest number of screen pixels per cell
+	zoomStep = 1.25 // zoom factor applied per scroll-wheel notch
+	gridZoom = 8    // the grid overlay is only drawn at this zoom or above
+)
+
+// view describes which part of the board is visible and how large each cell is drawn.
+// X and Y are the board coordinates shown in the top-left corner of the window.
+type view struct {
+	scale    float64
+	x, y     float64
+	fit      bool
+	grid     bool
+	dragging bool
+}
+
+// initialWindowSize picks a window size that fits the board on the display.
+// Small boards are magnified by a whole number so that cells stay square.
+func initialWindowSize(width, height int32) (int32, int32) {
+	bounds, err := sdl.GetDisplayUsableBounds(0)
+	if err != nil {
+		bounds = sdl.Rect{W: 1024, H: 768}
+	}
+	maxW := float64(bounds.W) * 0.9
+	maxH := float64(bounds.H) * 0.9
+	scale := math.Min(maxW/float64(width), maxH/float64(height))
+	if scale >= 1 {
+		scale = math.Min(math.Floor(scale), maxZoom)
+	}
+	return int32(math.Max(1, float64(width)*scale)), int32(math.Max(1, float64(height)*scale))
+}
+
+// boardSize is the size of the board in cells. Hex and triangle boards are a little wider or shorter than
+// their number of cells.
+func (w *Window) boardSize() (float64, float64) {
+	if w.shape != nil {
+		return w.shape.boardSize()
+	}
+	return float64(w.Width), float64(w.Height)
+}
+
+// minZoom is the smallest zoom allowed, where the whole board is a quarter of the window.
+func (w *Window) minZoom() float64 {
+	winW, winH := w.window.GetSize()
+	width, height := w.boardSize()
+	return math.Min(float64(winW)/width, float64(winH)/height) / 4
+}
+
+// FitToWindow scales the board to fill the window and centres it.
+// The board stays fitted when the window is resized, until the user zooms or pans.
+func (w *Window) FitToWindow() {
+	winW, winH := w.window.GetSize()
+	width, height := w.boardSize()
+	w.view.scale = math.Min(float64(winW)/width, float64(winH)/height)
+	w.view.x = (width - float64(winW)/w.view.scale) / 2
+	w.view.y = (height - float64(winH)/w.view.scale) / 2
+	w.view.fit = true
+}
+
+// ToggleGrid switches the cell grid overlay on or off.
+func (w *Window) ToggleGrid() {
+	w.view.grid = !w.view.grid
+}
+
+// Zoom multiplies the zoom level by factor, keeping the cell under the window position (px, py) in place.
+func (w *Window) Zoom(px, py int32, factor float64) {
+	scale := math.Max(w.minZoom(), math.Min(w.view.scale*factor, maxZoom))
+	boardX := w.view.x + float64(px)/w.view.scale
+	boardY := w.view.y + float64(py)/w.view.scale
+	w.view.x = boardX - float64(px)/scale
+	w.view.y = boardY - float64(py)/scale
+	w.view.scale = scale
+	w.view.fit = false
+}
+
+// ZoomCentre zooms around the centre of the window.
+func (w *Window) ZoomCentre(factor float64) {
+	winW, winH := w.window.GetSize()
+	w.Zoom(winW/2, winH/2, factor)
+}
+
+// Pan moves the view by (dx, dy) screen pixels.
+func (w *Window) Pan(dx, dy int32) {
+	w.view.x -= float64(dx) / w.view.scale
+	w.view.y -= float64(dy) / w.view.scale
+	w.view.fit = false
+}
+
+// HandleViewEvent updates the zoom and pan from mouse and window events.
+// It returns true if the view changed and the frame should be redrawn.
+func (w *Window) HandleViewEvent(event sdl.Event) bool {
+	switch e := event.(type) {
+	case *sdl.MouseWheelEvent:
+		notches := e.Y
+		if e.Direction == sdl.MOUSEWHEEL_FLIPPED {
+			notches = -notches
+		}
+		if notches == 0 {
+			return false
+		}
+		x, y, _ := sdl.GetMouseState()
+		w.Zoom(x, y, math.Pow(zoomStep, float64(notches)))
+		return true
+	case *sdl.MouseButtonEvent:
+		if e.Button == sdl.BUTTON_LEFT {
+			w.view.dragging = e.Type == sdl.MOUSEBUTTONDOWN
+		}
+	case *sdl.MouseMotionEvent:
+		if w.view.dragging {
+			w.Pan(e.XRel, e.YRel)
+			return true
+		}
+	case *sdl.WindowEvent:
+		if e.Event == sdl.WINDOWEVENT_SIZE_CHANGED {
+			if w.view.fit {
+				w.FitToWindow()
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// boardRect is where the whole board lands on the screen at the current zoom.
+// SDL clips anything that falls outside the window.
+func (w *Window) boardRect() sdl.Rect {
+	width, height := w.boardSize()
+	return sdl.Rect{
+		X: int32(math.Round(-w.view.x * w.view.scale)),
+		Y: int32(math.Round(-w.view.y * w.view.scale)),
+		W: int32(math.Round(width * w.view.scale)),
+		H: int32(math.Round(height * w.view.scale)),
+	}
+}
+
+// drawGrid draws lines between the visible cells once they are large enough to tell apart.
+// Only square cells have a grid.
+func (w *Window) drawGrid(board sdl.Rect) {
+	if !w.view.grid || w.view.scale < gridZoom || w.shape != nil {
+		return
+	}
+	winW, winH := w.window.GetSize()
+	top := int32(math.Max(0, float64(board.Y)))
+	bottom := int32(math.Min(float64(winH), float64(board.Y+board.H)))
+	left := int32(math.Max(0, float64(board.X)))
+	right := int32(math.Min(float64(winW), float64(board.X+board.W)))
+
+	err := w.renderer.SetDrawColor(0x40, 0x40, 0x40, 0xFF)
+	util.Check(err)
+	firstX := int(math.Max(0, math.Floor(w.view.x)))
+	lastX := int(math.Min(float64(w.Width), math.Ceil(w.view.x+float64(winW)/w.view.scale)))
+	for x := firstX; x <= lastX; x++ {
+		sx := int32(math.Round((float64(x) - w.view.x) * w.view.scale))
+		err = w.renderer.DrawLine(sx, top, sx, bottom)
+		util.Check(err)
+	}
+	firstY := int(math.Max(0, math.Floor(w.view.y)))
+	lastY := int(math.Min(float64(w.Height), math.Ceil(w.view.y+float64(winH)/w.view.scale)))
+	for y := firstY; y <= lastY; y++ {
+		sy := int32(math.Round((float64(y) - w.view.y) * w.view.scale))
+		err = w.renderer.DrawLine(left, sy, right, sy)
+		util.Check(err)
+	}
+	err = w.renderer.SetDrawColor(0, 0, 0, 0xFF)
+	util.Check(err)
+}
@@ -2,51 +2,90 @@ package sdl
 
 import (
 	"fmt"
+	"math"
 	"unsafe"
 	
 	"github.com/veandco/go-sdl2/sdl"
+	"uk.ac.bris.cs/gameoflife/gol"
 	"uk.ac.bris.cs/gameoflife/util"
 )
 
 type Window struct {
-	Width, Height int32
-	window        *sdl.Window
-	renderer      *sdl.Renderer
-	texture       *sdl.Texture
-	pixels        []byte
+	Width, Height      int32
+	window             *sdl.Window
+	renderer           *sdl.Renderer
+	texture            *sdl.Texture
+	pixels             []byte
+	view               view
+	colourMode         ColourMode
+	history            *cellHistory
+	overlay            []string
+	overlayHidden      bool
+	title              string
+	trajectories       map[int]*trajectory
+	trajectoriesHidden bool
+	shape              *cellShape // draws the cells of a hex or triangular grid; nil for square cells
 }
 
 func filterEvent(e sdl.Event, userdata interface{}) bool {
-	return e.GetType() == sdl.KEYDOWN || e.GetType() == sdl.QUIT
+	switch e.GetType() {
+	case sdl.KEYDOWN, sdl.QUIT, sdl.WINDOWEVENT,
+		sdl.MOUSEWHEEL, sdl.MOUSEMOTION, sdl.MOUSEBUTTONDOWN, sdl.MOUSEBUTTONUP:
+		return true
+	}
+	return false
 }
 
 func NewWindow(width, height int32) *Window {
+	return NewGridWindow(width, height, gol.SquareGrid)
+}
+
+// NewGridWindow opens a window for a width x height world whose cells have the shape of grid.
+func NewGridWindow(width, height int32, grid gol.Grid) *Window {
 	err := sdl.Init(sdl.INIT_EVERYTHING)
 	util.Check(err)
-	window, err := sdl.CreateWindow("GOL GUI", sdl.WINDOWPOS_CENTERED, sdl.WINDOWPOS_CENTERED, width, height, sdl.WINDOW_SHOWN)
+	var shape *cellShape
+	boardWidth, boardHeight := width, height
+	if grid != gol.SquareGrid {
+		shape = newCellShape(grid, width, height)
+		w, h := shape.boardSize()
+		boardWidth, boardHeight = int32(math.Ceil(w)), int32(math.Ceil(h))
+	}
+	windowWidth, windowHeight := initialWindowSize(boardWidth, boardHeight)
+	window, err := sdl.CreateWindow("GOL GUI", sdl.WINDOWPOS_CENTERED, sdl.WINDOWPOS_CENTERED, windowWidth, windowHeight, sdl.WINDOW_SHOWN|sdl.WINDOW_RESIZABLE)
 	util.Check(err)
 	renderer, err := sdl.CreateRenderer(window, -1, sdl.WINDOW_SHOWN)
 	util.Check(err)
-	sdl.SetHint(sdl.HINT_RENDER_SCALE_QUALITY, "linear")
-	err = renderer.SetLogicalSize(width, height)
-	util.Check(err)
+	// Nearest-neighbour scaling keeps cells sharp when zoomed in.
+	sdl.SetHint(sdl.HINT_RENDER_SCALE_QUALITY, "nearest")
 	texture, err := renderer.CreateTexture(sdl.PIXELFORMAT_ARGB8888, sdl.TEXTUREACCESS_STATIC, width, height)
 	util.Check(err)
+	if shape != nil {
+		shape.createTexture(renderer)
+	}
 
 	sdl.SetEventFilterFunc(filterEvent, nil)
-	return &Window{
-		width,
-		height,
-		window,
-		renderer,
-		texture,
-		make([]byte, width*height*4),
+	w := &Window{
+		Width:    width,
+		Height:   height,
+		window:   window,
+		renderer: renderer,
+		texture:  texture,
+		pixels:   make([]byte, width*height*4),
+		title:    "GOL GUI",
+		shape:    shape,
 	}
+	w.FitToWindow()
+	return w
 }
 
 func (w *Window) Destroy() {
 	err := w.texture.Destroy()
 	util.Check(err)
+	if w.shape != nil {
+		err = w.shape.texture.Destroy()
+		util.Check(err)
+	}
 	err = w.renderer.Destroy()
 	util.Check(err)
 	err = w.window.Destroy()
@@ -55,12 +94,25 @@ func (w *Window) Destroy() {
 }
 
 func (w *Window) RenderFrame() {
-	err := w.texture.Update(nil, unsafe.Pointer(&w.pixels[0]), int(w.Width*4))
-	util.Check(err)
-	err = w.renderer.Clear()
+	if w.colourMode != PlainColour {
+		w.recolour()
+	}
+	texture := w.texture
+	if w.shape != nil {
+		w.shape.update(w.pixels)
+		texture = w.shape.texture
+	} else {
+		err := w.texture.Update(nil, unsafe.Pointer(&w.pixels[0]), int(w.Width*4))
+		util.Check(err)
+	}
+	err := w.renderer.Clear()
 	util.Check(err)
-	err = w.renderer.Copy(w.texture, nil, nil)
+	board := w.boardRect()
+	err = w.renderer.Copy(texture, nil, &board)
 	util.Check(err)
+	w.drawGrid(board)
+	w.drawTrajectories()
+	w.drawOverlay()
 	w.renderer.Present()
 }
 
@@ -70,6 +122,9 @@ func (w *Window) PollEvent() sdl.Event {
 
 func (w *Window) SetPixel(x, y int) {
 	width := int(w.Width)
+	if w.history != nil && !w.history.alive[y*width+x] {
+		w.history.flip(y*width + x)
+	}
 	w.pixels[4*(y*width+x)+0] = 0xFF
 	w.pixels[4*(y*width+x)+1] = 0xFF
 	w.pixels[4*(y*width+x)+2] = 0xFF
@@ -82,6 +137,12 @@ func (w *Window) FlipPixel(x, y int) {
 	}
 
 	width := int(w.Width)
+	if w.history != nil {
+		w.history.flip(y*width + x)
+		if w.colourMode != PlainColour {
+			return
+		}
+	}
 	w.pixels[4*(y*width+x)+0] = ^w.pixels[4*(y*width+x)+0]
 	w.pixels[4*(y*width+x)+1] = ^w.pixels[4*(y*width+x)+1]
 	w.pixels[4*(y*width+x)+2] = ^w.pixels[4*(y*width+x)+2]
@@ -90,7 +151,15 @@ func (w *Window) FlipPixel(x, y int) {
 
 func (w *Window) CountPixels() int {
 	count := 0
-	for i := 0; i < int(w.Width) * int(w.Height) * 4; i += 4 {
+	if w.history != nil {
+		for _, alive := range w.history.alive {
+			if alive {
+				count++
+			}
+		}
+		return count
+	}
+	for i := 0; i < int(w.Width)*int(w.Height)*4; i += 4 {
 		if w.pixels[i] == 0xFF {
 			count++
 		}
@@ -102,4 +171,7 @@ func (w *Window) ClearPixels() {
 	for i := range w.pixels {
 		w.pixels[i] = 0
 	}
+	if w.history != nil {
+		w.startHistory()
+	}
 }
@@ -1,38 +1,32 @@
-package main
+package server
 
 import (
-	"flag"
 	"math/rand"
 	"net"
 	"net/rpc"
 	"time"
 	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/metrics"
 	"uk.ac.bris.cs/gameoflife/stubs"
 	"uk.ac.bris.cs/gameoflife/util"
 )
 
 // distributor.go acts as the client
 // server file is on the server
-// go run server/server.go
+// go run . serve
 // pressed green button in distributor
 
 // Secret method that we can't let clients see
-func nextState(world [][]uint8, p gol.Params, c gol.DistributorChannels) [][]uint8 {
+func nextState(world [][]uint8) [][]uint8 {
 
-	H := p.ImageHeight
-	W := p.ImageHeight
+	H := len(world)
+	W := len(world[0])
 
 	// make toReturn 2d slice
 	toReturn := make([][]uint8, H) // create a slice with 16 rows
 	for i := 0; i < H; i++ {
 		toReturn[i] = make([]uint8, W) // initialise each row with 16 columns
 	}
-	// fill in the 2d slice
-	for y := 0; y < H; y++ {
-		for x := 0; x < W; x++ {
-			toReturn[y][x] = <-c.IoInput
-		}
-	}
 
 	for y := 0; y < H; y++ {
 		for x := 0; x < W; x++ {
@@ -83,19 +77,35 @@ func nextState(world [][]uint8, p gol.Params, c gol.DistributorChannels) [][]uin
 	return toReturn
 }
 
-func doAllTurns(world [][]uint8, p gol.Params, c gol.DistributorChannels) [][]uint8 {
-	for i := 0; i < p.Turns; i++ {
-		world = nextState(world, p, c)
+func doAllTurns(world [][]uint8, turns int) [][]uint8 {
+	for i := 0; i < turns; i++ {
+		start := time.Now()
+		world = nextState(world)
+		gol.ObserveStep(0, time.Since(start))
 	}
 	return world
 }
 
 type SecretStringOperations struct{}
 
+var (
+	rpcCalls  = metrics.NewCounter("gol_server_rpc_calls_total", "RPC calls handled by the server.", "method")
+	rpcErrors = metrics.NewCounter("gol_server_rpc_errors_total", "RPC calls handled by the server that returned an error.", "method")
+)
+
+// countCall records an RPC call and, via defer, whether it failed.
+func countCall(method string, err *error) {
+	rpcCalls.Inc(method)
+	if *err != nil {
+		rpcErrors.Inc(method)
+	}
+}
+
 // this is like the Reverse method in SecretStrings
 func (s *SecretStringOperations) Update(req stubs.Request, res *stubs.Response) (err error) {
-	res.UpdatedWorld = doAllTurns(req.World, req.P, req.C)
-	// func nextState(world [][]uint8, p gol.Params, c gol.DistributorChannels) [][]uint8
+	defer countCall("Update", &err)
+	res.UpdatedWorld = doAllTurns(req.World, req.Turns)
+	return
 }
 
 func calculateAliveCells(world [][]byte) []util.Cell {
@@ -111,12 +121,10 @@ func calculateAliveCells(world [][]byte) []util.Cell {
 	return alives
 }
 
-func main() {
-	pAddr := flag.String("port", "8030", "Port to listen on")
-	flag.Parse()
+// Serve registers the RPC methods and answers calls on listener until it is closed.
+func Serve(listener net.Listener) {
 	rand.Seed(time.Now().UnixNano())
-	rpc.Register(&SecretStringOperations{})
-	listener, _ := net.Listen("tcp", ":"+*pAddr)
-	defer listener.Close()
-	rpc.Accept(listener)
+	server := rpc.NewServer()
+	util.Check(server.Register(&SecretStringOperations{}))
+	server.Accept(listener)
 }
@@ -0,0 +1,248 @@
+package soup
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"uk.ac.bris.cs/gameoflife/census"
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// Config describes a soup search.
+// Each soup is a SoupSize x SoupSize square of random cells in the middle of a BoardSize x BoardSize
+// torus, run until the world repeats or MaxTurns is reached.
+type Config struct {
+	Soups     int    // total number of soups to run, including any already done
+	Threads   int    // soups run in parallel
+	SoupSize  int    // width and height of the random region
+	BoardSize int    // width and height of the torus the soup is run on
+	MaxTurns  int    // turns after which a soup is given up on as unstable
+	Prefix    string // seeds are Prefix followed by the soup number
+	Progress  string // file used to save and resume progress; empty disables saving
+}
+
+// DefaultConfig returns a config for small soups on a board big enough to keep them apart from themselves.
+func DefaultConfig() Config {
+	return Config{
+		Soups:     1000,
+		Threads:   8,
+		SoupSize:  16,
+		BoardSize: 64,
+		MaxTurns:  5000,
+		Prefix:    "gol-",
+	}
+}
+
+// common objects are not recorded as finds.
+var common = map[string]bool{
+	"block": true, "blinker": true, "beehive": true, "glider": true, "loaf": true,
+	"boat": true, "ship": true, "tub": true, "pond": true, "long boat": true,
+	"barge": true, "toad": true, "beacon": true, "aircraft carrier": true, "mango": true,
+	"eater 1": true,
+}
+
+// Find is an unusual object and the seed of the soup that produced it.
+type Find struct {
+	Seed string
+	Name string
+	Code string
+}
+
+// Summary is the state of a search, saved to Config.Progress so that it can be resumed.
+// Next is the first soup not yet run; every soup before it has been counted.
+type Summary struct {
+	Next     int
+	Unstable int
+	Counts   map[string]int
+	Finds    []Find
+}
+
+type result struct {
+	index  int
+	stable bool
+	ash    []census.Object
+}
+
+// Search runs soups from the last saved progress, or from the start, until Config.Soups have been run.
+// Progress is saved every hundred soups and at the end.
+func Search(config Config) Summary {
+	summary := load(config.Progress)
+	jobs := make(chan int)
+	results := make(chan result)
+	threads := config.Threads
+	if threads < 1 {
+		threads = 1
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < threads; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range jobs {
+				results <- run(config, index)
+			}
+		}()
+	}
+	go func() {
+		for index := summary.Next; index < config.Soups; index++ {
+			jobs <- index
+		}
+		close(jobs)
+		wg.Wait()
+		close(results)
+	}()
+
+	// Results arrive out of order, so hold them back until every earlier soup is done.
+	// That way Next always marks a point the search can be resumed from.
+	waiting := make(map[int]result)
+	for r := range results {
+		waiting[r.index] = r
+		for {
+			done, ok := waiting[summary.Next]
+			if !ok {
+				break
+			}
+			delete(waiting, summary.Next)
+			summary.add(config, done)
+			if summary.Next%100 == 0 {
+				save(config.Progress, summary)
+				fmt.Printf("Soups %-8v %v unusual objects found\n", summary.Next, len(summary.Finds))
+			}
+		}
+	}
+	save(config.Progress, summary)
+	return summary
+}
+
+// add counts the ash of a soup. The objects left when an unstable soup is given up on are still
+// changing, so they are not ash and only the soup itself is counted.
+func (summary *Summary) add(config Config, r result) {
+	summary.Next++
+	if !r.stable {
+		summary.Unstable++
+		return
+	}
+	for _, object := range r.ash {
+		summary.Counts[object.Name]++
+		if !common[object.Name] {
+			summary.Finds = append(summary.Finds, Find{Seed: Seed(config, r.index), Name: object.Name, Code: object.Code})
+		}
+	}
+}
+
+// Seed returns the seed string of a soup.
+func Seed(config Config, index int) string {
+	return fmt.Sprintf("%v%v", config.Prefix, index)
+}
+
+// Generate returns the starting world of the soup with the given seed.
+// The same seed always gives the same soup, so finds can be reproduced.
+func Generate(config Config, seed string) [][]byte {
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte(seed))
+	random := rand.New(rand.NewSource(int64(hash.Sum64())))
+
+	world := make([][]byte, config.BoardSize)
+	for i := range world {
+		world[i] = make([]byte, config.BoardSize)
+	}
+	offset := (config.BoardSize - config.SoupSize) / 2
+	for y := 0; y < config.SoupSize; y++ {
+		for x := 0; x < config.SoupSize; x++ {
+			if random.Intn(2) == 1 {
+				world[offset+y][offset+x] = 255
+			}
+		}
+	}
+	return world
+}
+
+// run evolves one soup until it repeats a previous state and takes a census of what is left.
+func run(config Config, index int) result {
+	world := Generate(config, Seed(config, index))
+	seen := make(map[uint64]bool)
+	p := gol.Params{Threads: 1, ImageWidth: config.BoardSize, ImageHeight: config.BoardSize}
+	stable := false
+	for turn := 0; turn < config.MaxTurns; turn++ {
+		h := worldHash(world)
+		if seen[h] {
+			stable = true
+			break
+		}
+		seen[h] = true
+		world = gol.NextWorld(world, p)
+	}
+
+	var alive []util.Cell
+	for y, row := range world {
+		for x, cell := range row {
+			if cell != 0 {
+				alive = append(alive, util.Cell{X: x, Y: y})
+			}
+		}
+	}
+	return result{index: index, stable: stable, ash: census.Objects(alive, config.BoardSize, config.BoardSize)}
+}
+
+func worldHash(world [][]byte) uint64 {
+	hash := fnv.New64a()
+	for _, row := range world {
+		_, _ = hash.Write(row)
+	}
+	return hash.Sum64()
+}
+
+func load(path string) Summary {
+	summary := Summary{Counts: make(map[string]int)}
+	if path == "" {
+		return summary
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return summary
+	}
+	util.Check(err)
+	util.Check(json.Unmarshal(data, &summary))
+	if summary.Counts == nil {
+		summary.Counts = make(map[string]int)
+	}
+	fmt.Printf("Resuming soup search from soup %v\n", summary.Next)
+	return summary
+}
+
+// save writes the summary to a temporary file first so an interrupted save cannot lose progress.
+func save(path string, summary Summary) {
+	if path == "" {
+		return
+	}
+	data, err := json.MarshalIndent(summary, "", "  ")
+	util.Check(err)
+	util.Check(os.WriteFile(path+".tmp", data, 0644))
+	util.Check(os.Rename(path+".tmp", path))
+}
+
+// Report formats the summary: object counts across all soups, then every unusual find.
+func (summary Summary) Report() string {
+	var output []string
+	output = append(output, fmt.Sprintf("Soups searched %v, unstable %v\n", summary.Next, summary.Unstable))
+	output = append(output, census.Report(summary.Counts))
+	if len(summary.Finds) == 0 {
+		output = append(output, "No unusual objects found\n")
+		return strings.Join(output, "")
+	}
+	finds := append([]Find(nil), summary.Finds...)
+	sort.SliceStable(finds, func(i, j int) bool { return finds[i].Name < finds[j].Name })
+	output = append(output, "Unusual objects:\n")
+	for _, find := range finds {
+		output = append(output, fmt.Sprintf("  %-20v seed %-16v %v\n", find.Name, find.Seed, find.Code))
+	}
+	return strings.Join(output, "")
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/soup"
+)
+
+// TestSoup checks that a soup search gives the same summary whether or not it is interrupted and resumed.
+func TestSoup(t *testing.T) {
+	dir, err := os.MkdirTemp("", "soup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	config := soup.DefaultConfig()
+	config.Soups = 40
+	config.Threads = 4
+	config.Progress = filepath.Join(dir, "whole.json")
+	whole := soup.Search(config)
+	assert(t, whole.Next == 40, "Searched %v soups, expected 40\n", whole.Next)
+
+	config.Progress = filepath.Join(dir, "resumed.json")
+	config.Soups = 15
+	soup.Search(config)
+	config.Soups = 40
+	resumed := soup.Search(config)
+	assert(t, reflect.DeepEqual(whole, resumed), "Resumed search gave\n%v\nexpected\n%v", resumed.Report(), whole.Report())
+}
+
+// TestSoupUnstable checks that soups given up on as unstable add nothing to the counts or finds.
+func TestSoupUnstable(t *testing.T) {
+	config := soup.DefaultConfig()
+	config.Soups = 10
+	config.Threads = 2
+	config.MaxTurns = 1
+	config.Progress = ""
+	summary := soup.Search(config)
+	assert(t, summary.Unstable == 10, "%v of 10 soups stopped after one turn were unstable, expected all\n", summary.Unstable)
+	assert(t, len(summary.Counts) == 0 && len(summary.Finds) == 0, "Unstable soups left ash\n%v", summary.Report())
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestSparse checks that every engine gives the golden images for 16x16, 64x64 and 512x512 on 0, 1 and 100 turns,
+// and that the sparse engine keeps the 512x512 alive counts for 1000 turns.
+func TestSparse(t *testing.T) {
+	for _, engine := range []gol.EngineKind{gol.DenseEngine, gol.SparseEngine, gol.AutoEngine} {
+		for _, size := range []int{16, 64, 512} {
+			for _, turns := range []int{0, 1, 100} {
+				p := gol.Params{Turns: turns, Threads: 4, ImageWidth: size, ImageHeight: size, Engine: engine}
+				t.Run(fmt.Sprintf("%v-%dx%dx%d", engine, size, size, turns), func(t *testing.T) {
+					expected := readAliveCells(fmt.Sprintf("check/images/%vx%vx%v.pgm", size, size, turns), size, size)
+					events := make(chan gol.Event)
+					go gol.Run(p, events, nil)
+					var cells []util.Cell
+					for event := range events {
+						if e, ok := event.(gol.FinalTurnComplete); ok {
+							cells = e.Alive
+						}
+					}
+					assertEqualBoard(t, cells, expected, p)
+				})
+			}
+		}
+	}
+
+	world, err := gol.ReadPgm("images/512x512.pgm")
+	util.Check(err)
+	p := gol.Params{Turns: 1000, Threads: 4, Engine: gol.SparseEngine, StatsEvery: 1}
+	run, err := runDistributor(world, p, t.TempDir())
+	util.Check(err)
+	expected, err := readPopulations("check/alive/512x512.csv")
+	util.Check(err)
+	for i, count := range run.counts {
+		if count != expected[i+1] {
+			t.Fatalf("ERROR: At turn %v expected %v alive cells, got %v instead", i+1, expected[i+1], count)
+		}
+	}
+}
+
+// TestSparseEdits edits cells while paused and checks that the sparse engine carries on as the dense engine does.
+func TestSparseEdits(t *testing.T) {
+	var finals [][]util.Cell
+	for _, engine := range []gol.EngineKind{gol.DenseEngine, gol.SparseEngine} {
+		p := gol.Params{Turns: 1000, Threads: 2, ImageWidth: 64, ImageHeight: 64, Engine: engine}
+		events := make(chan gol.Event, 1000)
+		commands := make(chan gol.Command)
+		controller := gol.Controller(commands)
+		go gol.RunCommands(p, events, commands)
+
+		controller.Do(gol.Pause)
+		controller.Send(gol.Command{Kind: gol.Step, Turns: 10})
+		controller.Send(gol.Command{Kind: gol.EditCells, Cells: []util.Cell{{X: 1, Y: 1}, {X: 2, Y: 1}, {X: 3, Y: 1}}})
+		controller.Send(gol.Command{Kind: gol.Step, Turns: 20})
+		controller.Do(gol.Quit)
+		for event := range events {
+			if e, ok := event.(gol.FinalTurnComplete); ok {
+				finals = append(finals, e.Alive)
+			}
+		}
+	}
+	assert(t, reflect.DeepEqual(finals[0], finals[1]), "Sparse engine ended with %v alive cells after edits, dense with %v\n",
+		len(finals[1]), len(finals[0]))
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestStats checks the PopulationStats events added by gol.Run for a blinker on a 16x16 board.
+func TestStats(t *testing.T) {
+	p := gol.Params{ImageWidth: 16, ImageHeight: 16, Turns: 0, Threads: 1, StatsEvery: 1}
+	events := make(chan gol.Event, 1000)
+	statsEvents := make(chan gol.Event, 1000)
+	go gol.ReportStats(p, events, statsEvents)
+
+	// A blinker turning from horizontal to vertical.
+	for _, cell := range []util.Cell{{X: 4, Y: 5}, {X: 5, Y: 5}, {X: 6, Y: 5}} {
+		events <- gol.CellFlipped{CompletedTurns: 0, Cell: cell}
+	}
+	events <- gol.CellsFlipped{CompletedTurns: 1, Cells: []util.Cell{{X: 4, Y: 5}, {X: 6, Y: 5}, {X: 5, Y: 4}, {X: 5, Y: 6}}}
+	events <- gol.TurnComplete{CompletedTurns: 1}
+	close(events)
+
+	var stats []gol.PopulationStats
+	for event := range statsEvents {
+		if e, ok := event.(gol.PopulationStats); ok {
+			stats = append(stats, e)
+		}
+	}
+	if len(stats) != 1 {
+		t.Fatalf("ERROR: Expected 1 PopulationStats event, got %v", len(stats))
+	}
+	s := stats[0]
+	assert(t, s.Alive == 3, "Alive is %v, expected 3\n", s.Alive)
+	assert(t, s.Births == 2 && s.Deaths == 2, "Births/deaths are %v/%v, expected 2/2\n", s.Births, s.Deaths)
+	assert(t, s.MinX == 5 && s.MaxX == 5 && s.MinY == 4 && s.MaxY == 6,
+		"Bounding box is (%v,%v)-(%v,%v), expected (5,4)-(5,6)\n", s.MinX, s.MinY, s.MaxX, s.MaxY)
+	assert(t, s.CentreX == 5 && s.CentreY == 5, "Centre is (%v,%v), expected (5,5)\n", s.CentreX, s.CentreY)
+	assert(t, s.Density[1][1] == 3.0/16, "Density of region (1,1) is %v, expected %v\n", s.Density[1][1], 3.0/16)
+}
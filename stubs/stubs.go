@@ -1,7 +1,5 @@
 package stubs
 
-import "uk.ac.bris.cs/gameoflife/gol"
-
 var ReverseHandler = "SecretStringOperations.Reverse"
 var PremiumReverseHandler = "SecretStringOperations.FastReverse"
 
@@ -9,8 +7,33 @@ type Response struct {
 	UpdatedWorld [][]uint8
 }
 
+// Request asks the server to run Turns turns of World.
 type Request struct {
 	World [][]uint8
-	P     gol.Params
-	C     gol.DistributorChannels
+	Turns int
+}
+
+var NextStripHandler = "Worker.NextStrip"
+var BrokerTurnsHandler = "Broker.Turns"
+
+// StripRequest asks a worker for rows StartY to EndY-1 of the turn after World.
+type StripRequest struct {
+	World  [][]uint8
+	StartY int
+	EndY   int
+}
+
+type StripResponse struct {
+	Rows [][]uint8
+}
+
+// TurnsRequest asks a broker to run Turns turns of World, split between its workers.
+type TurnsRequest struct {
+	World [][]uint8
+	Turns int
+}
+
+type TurnsResponse struct {
+	World          [][]uint8
+	CompletedTurns int
 }
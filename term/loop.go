@@ -0,0 +1,144 @@
+package term
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// FPS is the most frames per second drawn, kept low so slow SSH links can keep up.
+const FPS = 10
+
+const (
+	clearScreen = "\x1b[2J"
+	cursorHome  = "\x1b[H"
+	clearToEnd  = "\x1b[J"
+	hideCursor  = "\x1b[?25l"
+	showCursor  = "\x1b[?25h"
+)
+
+// Run draws the board in the terminal using ANSI escape codes and forwards p, s, q and k
+// keypresses from stdin. It is a peer of sdl.Run for machines without a display.
+func Run(p gol.Params, events <-chan gol.Event, keyPresses chan<- rune) {
+	restore, err := makeRaw()
+	if err == nil {
+		defer restore()
+	}
+	fmt.Print(clearScreen, hideCursor)
+	defer fmt.Print(showCursor)
+
+	go readKeys(keyPresses)
+
+	world := make([][]uint8, p.ImageHeight)
+	for i := range world {
+		world[i] = make([]uint8, p.ImageWidth)
+	}
+	avgTurns := util.NewAvgTurns()
+	turns, alive, rate := 0, 0, 0
+	state := gol.Paused
+	message := ""
+	dirty := false
+	refreshTicker := time.NewTicker(time.Second / time.Duration(FPS))
+	defer refreshTicker.Stop()
+
+	draw := func() {
+		cols, rows := size()
+		frame := util.MatrixToBlocks(world, p.ImageWidth, p.ImageHeight, cols, rows-2)
+		fmt.Print(cursorHome, clearToEnd, frame)
+		fmt.Printf("Turn %-8v Alive %-8v %5v turns/sec  %-9v %v\n", turns, alive, rate, state, message)
+	}
+
+term:
+	for {
+		select {
+		case <-refreshTicker.C:
+			if dirty {
+				draw()
+				dirty = false
+			}
+
+		case event, ok := <-events:
+			if !ok {
+				break term
+			}
+			switch e := event.(type) {
+			case gol.CellFlipped:
+				world[e.Cell.Y][e.Cell.X] = ^world[e.Cell.Y][e.Cell.X]
+			case gol.CellsFlipped:
+				for _, cell := range e.Cells {
+					world[cell.Y][cell.X] = ^world[cell.Y][cell.X]
+				}
+			case gol.TurnComplete:
+				turns = e.CompletedTurns
+				dirty = true
+			case gol.AliveCellsCount:
+				turns = e.CompletedTurns
+				alive = e.CellsCount
+				rate = avgTurns.Get(e.CompletedTurns)
+				dirty = true
+			case gol.FinalTurnComplete:
+				turns = e.CompletedTurns
+				alive = len(e.Alive)
+				message = event.String()
+				dirty = true
+			case gol.ImageOutputComplete, gol.BreakpointHit:
+				message = event.String()
+				dirty = true
+			case gol.StateChange:
+				turns = e.CompletedTurns
+				state = e.NewState
+				dirty = true
+				if e.NewState == gol.Quitting {
+					break term
+				}
+			}
+		}
+	}
+	draw()
+}
+
+// readKeys forwards the keys understood by the distributor from stdin.
+func readKeys(keyPresses chan<- rune) {
+	buf := make([]byte, 64)
+	for {
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			return
+		}
+		for _, key := range ParseKeys(buf[:n]) {
+			keyPresses <- key
+		}
+	}
+}
+
+// ParseKeys returns the keys understood by the distributor in one read from a raw terminal.
+// Escape quits, as it does in the SDL window, but the escape sequences sent by arrow, function and
+// Alt keys are skipped. A terminal writes each sequence at once, so an escape at the end of a read, or
+// followed by another, is a press of the Escape key itself.
+func ParseKeys(data []byte) []rune {
+	var keys []rune
+	for i := 0; i < len(data); i++ {
+		switch c := data[i]; {
+		case c == 'p' || c == 's' || c == 'q' || c == 'k':
+			keys = append(keys, rune(c))
+		case c == 0x1b && (i+1 == len(data) || data[i+1] == 0x1b):
+			keys = append(keys, 'q')
+		case c == 0x1b && data[i+1] == '[':
+			// A control sequence runs until its final byte, from @ to ~.
+			i += 2
+			for i < len(data) && (data[i] < 0x40 || data[i] > 0x7e) {
+				i++
+			}
+		case c == 0x1b:
+			// ESC O and a letter on some terminals, or ESC and the key pressed with Alt.
+			i++
+			if data[i] == 'O' {
+				i++
+			}
+		}
+	}
+	return keys
+}
@@ -0,0 +1,47 @@
+package term
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// stty runs the stty command against the terminal on stdin and returns its output.
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+// makeRaw switches the terminal to reading single keypresses without echoing them.
+// It returns a function that restores the previous terminal settings.
+func makeRaw() (restore func(), err error) {
+	saved, err := stty("-g")
+	if err != nil {
+		return nil, err
+	}
+	if _, err = stty("-icanon", "-echo", "min", "1"); err != nil {
+		return nil, err
+	}
+	return func() {
+		_, _ = stty(saved)
+	}, nil
+}
+
+// size returns the number of columns and rows in the terminal, or 80x24 if it cannot be found.
+func size() (cols, rows int) {
+	out, err := stty("size")
+	if err == nil {
+		fields := strings.Fields(out)
+		if len(fields) == 2 {
+			rows, rowsErr := strconv.Atoi(fields[0])
+			cols, colsErr := strconv.Atoi(fields[1])
+			if rowsErr == nil && colsErr == nil && rows > 0 && cols > 0 {
+				return cols, rows
+			}
+		}
+	}
+	return 80, 24
+}
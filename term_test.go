@@ -0,0 +1,53 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/term"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestMatrixToBlocks checks that each character draws a 2x2 group of cells, that big worlds are scaled down
+// to fit, and that a terminal with no room left still gets a character rather than hanging.
+func TestMatrixToBlocks(t *testing.T) {
+	world := [][]uint8{
+		{255, 0, 0, 0},
+		{255, 255, 0, 255},
+	}
+	frame := util.MatrixToBlocks(world, 4, 2, 80, 24)
+	assert(t, frame == "▙▗\n", "4x2 world drawn as %q, expected %q\n", frame, "▙▗\n")
+
+	// Each quadrant now covers 2x2 cells, and both on the top row hold an alive cell.
+	frame = util.MatrixToBlocks(world, 4, 2, 1, 1)
+	assert(t, frame == "▀\n", "4x2 world in one character drawn as %q, expected %q\n", frame, "▀\n")
+
+	for _, size := range [][2]int{{0, 24}, {-3, -3}} {
+		frame = util.MatrixToBlocks(world, 4, 2, size[0], size[1])
+		assert(t, frame == "▀\n", "4x2 world in %vx%v drawn as %q, expected %q\n", size[0], size[1], frame, "▀\n")
+	}
+	frame = util.MatrixToBlocks(world, 4, 2, 80, 0)
+	assert(t, frame == "▙▗\n", "4x2 world with no rows drawn as %q, expected %q\n", frame, "▙▗\n")
+}
+
+// TestParseKeys checks that keys are forwarded from the terminal, and that Escape quits but arrow keys do not.
+func TestParseKeys(t *testing.T) {
+	tests := []struct {
+		input string
+		keys  []rune
+	}{
+		{"p", []rune{'p'}},
+		{"sqkx", []rune{'s', 'q', 'k'}},
+		{"\x1b", []rune{'q'}},
+		{"\x1b\x1b", []rune{'q', 'q'}},
+		{"\x1b[A\x1b[B\x1b[C\x1b[D", nil},
+		{"p\x1b[1;5Cs", []rune{'p', 's'}},
+		{"\x1bOA", nil},
+		{"\x1b[15~", nil},
+		{"\x1bp", nil},
+	}
+	for _, test := range tests {
+		keys := term.ParseKeys([]byte(test.input))
+		assert(t, reflect.DeepEqual(keys, test.keys), "Read %q as keys %q, expected %q\n", test.input, keys, test.keys)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestTiles checks that a TiledWorld keeps to NextWorld for 1000 turns, flipping the same cells in the same order,
+// including on a board whose width is not a multiple of gol.TileSize.
+func TestTiles(t *testing.T) {
+	for _, size := range []int{16, 64, 512} {
+		for _, threads := range []int{1, 3, 8} {
+			t.Run(fmt.Sprintf("%dx%d-%d", size, size, threads), func(t *testing.T) {
+				world, err := gol.ReadPgm(fmt.Sprintf("images/%vx%v.pgm", size, size))
+				util.Check(err)
+				checkTiles(t, world, threads, 1000)
+			})
+		}
+	}
+
+	world := make([][]byte, 20)
+	for y := range world {
+		world[y] = make([]byte, 27)
+	}
+	// A glider heading south-east, crossing the narrower tiles at the edges.
+	for _, cell := range []util.Cell{{X: 6, Y: 5}, {X: 7, Y: 6}, {X: 5, Y: 7}, {X: 6, Y: 7}, {X: 7, Y: 7}} {
+		world[cell.Y][cell.X] = 255
+	}
+	checkTiles(t, world, 2, 200)
+}
+
+func checkTiles(t *testing.T, world [][]byte, threads, turns int) {
+	start := make([][]byte, len(world))
+	for y := range world {
+		start[y] = append([]byte(nil), world[y]...)
+	}
+	tiles := gol.NewTiledWorld(start, threads)
+	for turn := 1; turn <= turns; turn++ {
+		next := gol.NextWorld(world, gol.Params{Threads: threads})
+		var expected []util.Cell
+		for y := range next {
+			for x := range next[y] {
+				if next[y][x] != world[y][x] {
+					expected = append(expected, util.Cell{X: x, Y: y})
+				}
+			}
+		}
+		world = next
+		flipped := tiles.Next()
+		if len(flipped) != len(expected) || (len(flipped) > 0 && !reflect.DeepEqual(flipped, expected)) {
+			t.Fatalf("ERROR: Turn %v flipped %v cells, NextWorld flipped %v", turn, len(flipped), len(expected))
+		}
+		if !reflect.DeepEqual(tiles.World(), world) {
+			t.Fatalf("ERROR: Turn %v differs from NextWorld", turn)
+		}
+	}
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"uk.ac.bris.cs/gameoflife/bench"
+	"uk.ac.bris.cs/gameoflife/census"
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/soup"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+func benchCommand(args []string) {
+	flags := newFlagSet("bench")
+	config := bench.DefaultConfig()
+	config.Images = loadConfig(args).Input
+	sizes := flags.String(
+		"sizes",
+		joinInts(config.Sizes),
+		"Comma-separated board sizes to measure. Sizes without an image in -images get a random world.")
+	threads := flags.String(
+		"t",
+		joinInts(config.Threads),
+		"Comma-separated thread counts to measure. For the distributed engine these are numbers of workers.")
+	engines := flags.String(
+		"engines",
+		strings.Join(config.Engines, ","),
+		"Comma-separated engines to measure: "+strings.Join(bench.Engines, ", ")+".")
+	flags.IntVar(
+		&config.Turns,
+		"turns",
+		config.Turns,
+		"Turns timed in each repetition.")
+	flags.IntVar(
+		&config.Warmup,
+		"warmup",
+		config.Warmup,
+		"Turns run before timing starts. Each repetition starts from the world they end with, so a board can settle first.")
+	flags.IntVar(
+		&config.Reps,
+		"reps",
+		config.Reps,
+		"Number of timed repetitions.")
+	flags.StringVar(
+		&config.Images,
+		"images",
+		config.Images,
+		"Directory to read <size>x<size>.pgm from.")
+	flags.StringVar(
+		&config.Worker,
+		"worker",
+		"",
+		"Address of the worker for the rpc engine. By default one is started in this process.")
+	flags.StringVar(
+		&config.Broker,
+		"broker",
+		"",
+		"Address of the broker for the distributed engine. By default one is started in this process.")
+	output := flags.String(
+		"o",
+		"",
+		"Save the report to this file, as .json or .csv.")
+	baseline := flags.String(
+		"baseline",
+		"",
+		"Compare with a report saved earlier as .json.")
+	tolerance := flags.Float64(
+		"tolerance",
+		0.05,
+		"Smallest change from the baseline, as a fraction, that is marked as faster or slower.")
+	flags.Parse(args)
+
+	var err error
+	config.Sizes, err = parseInts(*sizes)
+	util.Check(err)
+	config.Threads, err = parseInts(*threads)
+	util.Check(err)
+	config.Engines = strings.Split(*engines, ",")
+
+	report, err := bench.Run(config)
+	util.Check(err)
+	if *output != "" {
+		util.Check(report.Save(*output))
+	}
+	if *baseline != "" {
+		old, err := bench.Load(*baseline)
+		util.Check(err)
+		fmt.Print(bench.Compare(old, report, *tolerance))
+	}
+}
+
+func convertCommand(args []string) {
+	flags := newFlagSet("convert")
+	threshold := flags.Int(
+		"threshold",
+		128,
+		"Grey level from which cells of greyscale input are alive.")
+	crop := flags.Bool(
+		"crop",
+		false,
+		"Crop the pattern to the bounding box of its alive cells.")
+	size := flags.String(
+		"size",
+		"",
+		"Put the pattern on a board of this size, such as 512x512, padding it with dead cells.")
+	centre := flags.Bool(
+		"centre",
+		false,
+		"Centre the alive cells on the board instead of keeping their position.")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: go run . convert [flags] <input> <output>\n"+
+			"The formats are picked by file extension: %v\n"+
+			"The rule of the input is kept in output formats that record one.\n\nFlags:\n", strings.Join(gol.PatternFormats, " "))
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 2 || *threshold < 1 || *threshold > 255 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	world, rule, err := gol.ReadPatternRule(flags.Arg(0))
+	util.Check(err)
+	world = gol.Threshold(world, byte(*threshold))
+	width, height := worldWidth(world), len(world)
+	if *size != "" {
+		_, err := fmt.Sscanf(*size, "%dx%d", &width, &height)
+		util.Check(err)
+	}
+	x, y := 0, 0
+	if *crop || *centre {
+		world, x, y = gol.Crop(world)
+		if *crop {
+			x, y = 0, 0
+			if *size == "" {
+				width, height = worldWidth(world), len(world)
+			}
+		}
+		if *centre {
+			x, y = (width-worldWidth(world))/2, (height-len(world))/2
+		}
+	}
+	board := gol.Place(world, width, height, x, y)
+	if len(aliveCells(board)) != len(aliveCells(world)) {
+		util.Check(fmt.Errorf("alive cells fall outside the %vx%v board", width, height))
+	}
+	util.Check(gol.WritePattern(flags.Arg(1), board, rule))
+	fmt.Printf("Wrote %vx%v to %v\n", width, height, flags.Arg(1))
+}
+
+func diffCommand(args []string) {
+	flags := newFlagSet("diff")
+	context := flags.Int(
+		"context",
+		2,
+		"Cells of context drawn around each difference. Differences closer than about twice this share a region.")
+	regions := flags.Int(
+		"regions",
+		10,
+		"Most regions to draw in the terminal.")
+	pngPath := flags.String(
+		"png",
+		"",
+		"Also save an image of both worlds with the differences highlighted to this file.")
+	colour := flags.Bool(
+		"colour",
+		isTerminal(os.Stdout),
+		"Draw differences in colour. The default is to use colour when writing to a terminal.")
+	threshold := flags.Int(
+		"threshold",
+		128,
+		"Grey level from which cells of greyscale input are alive.")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: go run . diff [flags] <world> <expected>\n"+
+			"Exits with status 1 if the worlds differ. The formats are picked by file extension: %v\n\nFlags:\n",
+			strings.Join(gol.PatternFormats, " "))
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 2 || *threshold < 1 || *threshold > 255 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	got, err := gol.ReadPattern(flags.Arg(0))
+	util.Check(err)
+	expected, err := gol.ReadPattern(flags.Arg(1))
+	util.Check(err)
+	got, expected = gol.Threshold(got, byte(*threshold)), gol.Threshold(expected, byte(*threshold))
+	d := util.DiffWorlds(got, expected)
+	fmt.Print(d.Summary(*context))
+	if *pngPath != "" {
+		// Small worlds are scaled up so that single cells can be seen.
+		scale := 1
+		for (d.Width*scale < 512 || d.Height*scale < 512) && scale < 16 {
+			scale *= 2
+		}
+		util.Check(d.WritePng(*pngPath, got, expected, scale))
+	}
+	if d.Empty() {
+		return
+	}
+	fmt.Print(d.Render(got, expected, *context, *regions, *colour))
+	os.Exit(1)
+}
+
+// isTerminal reports whether f is a terminal rather than a file or pipe.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// worldWidth returns the width of a world, which is 0 if it has no rows.
+func worldWidth(world [][]byte) int {
+	if len(world) == 0 {
+		return 0
+	}
+	return len(world[0])
+}
+
+func censusCommand(args []string) {
+	flags := newFlagSet("census")
+	params, err := loadConfig(args).Params()
+	util.Check(err)
+	params.Turns = 0
+	paramsFlags(flags, &params)
+	input := flags.String(
+		"in",
+		"",
+		"Pattern to take the census of, instead of <w>x<h>.pgm in the images directory.")
+	soups := flags.Int(
+		"soups",
+		0,
+		"Run a soup search over this many random soups instead, using -t threads.")
+	soupPrefix := flags.String(
+		"soupprefix",
+		soup.DefaultConfig().Prefix,
+		"Prefix of the seeds used to generate soups.")
+	soupProgress := flags.String(
+		"soupprogress",
+		"soups.json",
+		"File used to save soup search progress, so an interrupted search can be resumed.")
+	flags.Parse(args)
+
+	if *soups > 0 {
+		config := soup.DefaultConfig()
+		config.Soups = *soups
+		config.Threads = params.Threads
+		config.Prefix = *soupPrefix
+		config.Progress = *soupProgress
+		fmt.Print(soup.Search(config).Report())
+		return
+	}
+
+	path := *input
+	if path == "" {
+		path = params.InputPath()
+	}
+	world, rule, err := gol.ReadPatternRule(path)
+	util.Check(err)
+	// NextWorld and the census only know Conway's rule, so a pattern for another one would be counted wrongly.
+	if rule != gol.Conway || params.Rule != gol.Conway {
+		util.Check(fmt.Errorf("the census only recognises patterns of %v", gol.ConwayRule))
+	}
+	for turn := 0; turn < params.Turns; turn++ {
+		world = gol.NextWorld(world, params)
+	}
+	fmt.Printf("Census at turn %v\n%v", params.Turns, census.Report(census.Count(aliveCells(world), len(world[0]), len(world))))
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestTracking runs a glider across the edge of a 16x16 board and checks it is followed as one spaceship.
+func TestTracking(t *testing.T) {
+	p := gol.Params{ImageWidth: 16, ImageHeight: 16, Turns: 64, Threads: 2, TrackEvery: 4}
+	events := make(chan gol.Event, 1000)
+	trackEvents := make(chan gol.Event, 1000)
+	go gol.TrackSpaceships(p, events, trackEvents)
+
+	world := make([][]byte, p.ImageHeight)
+	for y := range world {
+		world[y] = make([]byte, p.ImageWidth)
+	}
+	// A glider heading south-east.
+	for _, cell := range []util.Cell{{X: 6, Y: 5}, {X: 7, Y: 6}, {X: 5, Y: 7}, {X: 6, Y: 7}, {X: 7, Y: 7}} {
+		world[cell.Y][cell.X] = 255
+		events <- gol.CellFlipped{CompletedTurns: 0, Cell: cell}
+	}
+	go func() {
+		for turn := 1; turn <= p.Turns; turn++ {
+			next := gol.NextWorld(world, p)
+			var flipped []util.Cell
+			for y := range next {
+				for x := range next[y] {
+					if next[y][x] != world[y][x] {
+						flipped = append(flipped, util.Cell{X: x, Y: y})
+					}
+				}
+			}
+			world = next
+			events <- gol.CellsFlipped{CompletedTurns: turn, Cells: flipped}
+			events <- gol.TurnComplete{CompletedTurns: turn}
+		}
+		close(events)
+	}()
+
+	var sightings []gol.SpaceshipSeen
+	for event := range trackEvents {
+		if e, ok := event.(gol.SpaceshipSeen); ok {
+			sightings = append(sightings, e)
+		}
+	}
+	if len(sightings) != p.Turns/p.TrackEvery {
+		t.Fatalf("ERROR: Expected %v SpaceshipSeen events, got %v", p.Turns/p.TrackEvery, len(sightings))
+	}
+	for _, seen := range sightings {
+		assert(t, seen.Name == "glider", "Turn %v: saw a %v, expected a glider\n", seen.CompletedTurns, seen.Name)
+		assert(t, seen.ID == sightings[0].ID, "Turn %v: glider has ID %v, expected %v\n", seen.CompletedTurns, seen.ID, sightings[0].ID)
+		assert(t, seen.Direction() == "SE", "Turn %v: glider heading %v, expected SE\n", seen.CompletedTurns, seen.Direction())
+		assert(t, seen.Speed() == "c/4", "Turn %v: glider moving at %v, expected c/4\n", seen.CompletedTurns, seen.Speed())
+	}
+}
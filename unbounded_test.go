@@ -0,0 +1,95 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// runCells runs cells placed on a p.ImageWidth x p.ImageHeight image without a viewer, editing edits in
+// first if there are any, and returns the final alive cells and the last image saved.
+func runCells(t *testing.T, p gol.Params, cells, edits []util.Cell) ([]util.Cell, string) {
+	world := make([][]byte, p.ImageHeight)
+	for y := range world {
+		world[y] = make([]byte, p.ImageWidth)
+	}
+	for _, cell := range cells {
+		world[cell.Y][cell.X] = 255
+	}
+	p, err := writeInput(world, p, t.TempDir())
+	util.Check(err)
+
+	events := make(chan gol.Event, 1000)
+	commands := make(chan gol.Command)
+	go gol.RunCommands(p, events, commands)
+	if len(edits) > 0 {
+		controller := gol.Controller(commands)
+		controller.Do(gol.Pause)
+		controller.Send(gol.Command{Kind: gol.EditCells, Cells: edits})
+		controller.Do(gol.Resume)
+	}
+	run := collectRun(p, events)
+	return run.alive, run.saved
+}
+
+func shift(cells []util.Cell, dx, dy int) []util.Cell {
+	shifted := make([]util.Cell, len(cells))
+	for i, cell := range cells {
+		shifted[i] = util.Cell{X: cell.X + dx, Y: cell.Y + dy}
+	}
+	return shifted
+}
+
+// TestUnbounded runs a glider off the edge of a 16x16 image and checks that it keeps going, and that the
+// final snapshot is cropped to it with its offset recorded.
+func TestUnbounded(t *testing.T) {
+	// A glider heading south-east, in row-major order.
+	glider := []util.Cell{{X: 6, Y: 5}, {X: 7, Y: 6}, {X: 5, Y: 7}, {X: 6, Y: 7}, {X: 7, Y: 7}}
+	p := gol.Params{Turns: 100, Threads: 2, ImageWidth: 16, ImageHeight: 16, Unbounded: true}
+	final, saved := runCells(t, p, glider, nil)
+	expected := shift(glider, 25, 25)
+	assert(t, reflect.DeepEqual(final, expected), "Glider ended at %v after 100 turns, expected %v\n", final, expected)
+
+	assert(t, filepath.Base(saved) == "3x3x100.pgm", "Final snapshot is %v, expected 3x3x100.pgm\n", filepath.Base(saved))
+	world, offset, err := gol.ReadPgmAt(saved)
+	util.Check(err)
+	assert(t, offset == util.Cell{X: 30, Y: 30}, "Final snapshot is at %v, expected (30, 30)\n", offset)
+	var cells []util.Cell
+	for y := range world {
+		for x := range world[y] {
+			if world[y][x] != 0 {
+				cells = append(cells, util.Cell{X: x + offset.X, Y: y + offset.Y})
+			}
+		}
+	}
+	assert(t, reflect.DeepEqual(cells, expected), "Final snapshot holds %v, expected %v\n", cells, expected)
+
+	// Gliders heading north-west go to negative coordinates.
+	reversed := []util.Cell{{X: 8, Y: 8}, {X: 9, Y: 8}, {X: 10, Y: 8}, {X: 8, Y: 9}, {X: 9, Y: 10}}
+	final, _ = runCells(t, p, reversed, nil)
+	expected = shift(reversed, -25, -25)
+	assert(t, reflect.DeepEqual(final, expected), "Glider ended at %v after 100 turns, expected %v\n", final, expected)
+
+	// A block edited in at negative coordinates stays there.
+	block := []util.Cell{{X: -10, Y: -10}, {X: -9, Y: -10}, {X: -10, Y: -9}, {X: -9, Y: -9}}
+	p.Turns = 10
+	final, _ = runCells(t, p, nil, block)
+	assert(t, reflect.DeepEqual(final, block), "Block ended as %v, expected %v\n", final, block)
+}
+
+// TestUnboundedMatchesBounded checks an R-pentomino against the same pattern on a board big enough that it
+// never reaches the edges, so wrapping cannot make a difference.
+func TestUnboundedMatchesBounded(t *testing.T) {
+	rPentomino := []util.Cell{{X: 8, Y: 7}, {X: 9, Y: 7}, {X: 7, Y: 8}, {X: 8, Y: 8}, {X: 8, Y: 9}}
+	p := gol.Params{Turns: 200, Threads: 2, ImageWidth: 16, ImageHeight: 16, Unbounded: true}
+	final, _ := runCells(t, p, rPentomino, nil)
+
+	p = gol.Params{Turns: 200, Threads: 4, ImageWidth: 256, ImageHeight: 256}
+	expected, _ := runCells(t, p, shift(rPentomino, 120, 120), nil)
+	expected = shift(expected, -120, -120)
+	assert(t, reflect.DeepEqual(final, expected), "Unbounded run has %v alive cells after 200 turns, bounded has %v\n",
+		len(final), len(expected))
+}
@@ -0,0 +1,255 @@
+package util
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"strings"
+)
+
+// WorldDiff is how a world differs from the world it was expected to be.
+// Worlds of different sizes are compared as if the smaller one were padded with dead cells.
+type WorldDiff struct {
+	Width, Height int
+	Extra         []Cell // alive, but expected to be dead
+	Missing       []Cell // dead, but expected to be alive
+}
+
+// DiffRegion is a rectangle of the world around a group of nearby differences, inclusive of its corners.
+type DiffRegion struct {
+	MinX, MinY, MaxX, MaxY int
+	Extra, Missing         int
+}
+
+const (
+	diffReset   = "\x1b[0m"
+	diffRed     = "\x1b[31m"
+	diffGreen   = "\x1b[32m"
+	diffMaxRows = 64 // rows and columns of a region that Render draws before cutting it short
+	diffListed  = 20 // regions listed by Summary
+)
+
+// DiffWorlds compares got with expected cell by cell.
+func DiffWorlds(got, expected [][]uint8) WorldDiff {
+	d := WorldDiff{Height: len(got)}
+	if len(expected) > d.Height {
+		d.Height = len(expected)
+	}
+	for _, world := range [][][]uint8{got, expected} {
+		if len(world) > 0 && len(world[0]) > d.Width {
+			d.Width = len(world[0])
+		}
+	}
+	for y := 0; y < d.Height; y++ {
+		for x := 0; x < d.Width; x++ {
+			isAlive, wantAlive := cellAlive(got, x, y), cellAlive(expected, x, y)
+			if isAlive && !wantAlive {
+				d.Extra = append(d.Extra, Cell{x, y})
+			} else if !isAlive && wantAlive {
+				d.Missing = append(d.Missing, Cell{x, y})
+			}
+		}
+	}
+	return d
+}
+
+func cellAlive(world [][]uint8, x, y int) bool {
+	return y < len(world) && x < len(world[y]) && world[y][x] != 0
+}
+
+// Empty reports whether the worlds are the same.
+func (d WorldDiff) Empty() bool {
+	return len(d.Extra) == 0 && len(d.Missing) == 0
+}
+
+// Regions groups the differences into rectangles, each extended by context cells on every side.
+// The world is split into tiles about twice context across, and differences in touching tiles share a region.
+func (d WorldDiff) Regions(context int) []DiffRegion {
+	if context < 0 {
+		context = 0
+	}
+	// Mark tiles holding a difference, then join neighbouring marked tiles into regions.
+	tile := 2*context + 1
+	if tile < 8 {
+		tile = 8
+	}
+	tiles := make(map[Cell]*DiffRegion)
+	mark := func(cells []Cell, extra bool) {
+		for _, cell := range cells {
+			key := Cell{cell.X / tile, cell.Y / tile}
+			r, ok := tiles[key]
+			if !ok {
+				r = &DiffRegion{MinX: cell.X, MinY: cell.Y, MaxX: cell.X, MaxY: cell.Y}
+				tiles[key] = r
+			}
+			r.add(cell, extra)
+		}
+	}
+	mark(d.Extra, true)
+	mark(d.Missing, false)
+
+	var regions []DiffRegion
+	seen := make(map[Cell]bool)
+	for y := 0; y <= d.Height/tile; y++ {
+		for x := 0; x <= d.Width/tile; x++ {
+			start := Cell{x, y}
+			if _, ok := tiles[start]; !ok || seen[start] {
+				continue
+			}
+			region := *tiles[start]
+			seen[start] = true
+			queue := []Cell{start}
+			for len(queue) > 0 {
+				next := queue[0]
+				queue = queue[1:]
+				for dy := -1; dy <= 1; dy++ {
+					for dx := -1; dx <= 1; dx++ {
+						neighbour := Cell{next.X + dx, next.Y + dy}
+						if r, ok := tiles[neighbour]; ok && !seen[neighbour] {
+							seen[neighbour] = true
+							region.merge(*r)
+							queue = append(queue, neighbour)
+						}
+					}
+				}
+			}
+			region.MinX, region.MinY = maxInt(region.MinX-context, 0), maxInt(region.MinY-context, 0)
+			region.MaxX, region.MaxY = minInt(region.MaxX+context, d.Width-1), minInt(region.MaxY+context, d.Height-1)
+			regions = append(regions, region)
+		}
+	}
+	return regions
+}
+
+func (r *DiffRegion) add(cell Cell, extra bool) {
+	r.MinX, r.MaxX = minInt(r.MinX, cell.X), maxInt(r.MaxX, cell.X)
+	r.MinY, r.MaxY = minInt(r.MinY, cell.Y), maxInt(r.MaxY, cell.Y)
+	if extra {
+		r.Extra++
+	} else {
+		r.Missing++
+	}
+}
+
+func (r *DiffRegion) merge(other DiffRegion) {
+	r.MinX, r.MaxX = minInt(r.MinX, other.MinX), maxInt(r.MaxX, other.MaxX)
+	r.MinY, r.MaxY = minInt(r.MinY, other.MinY), maxInt(r.MaxY, other.MaxY)
+	r.Extra += other.Extra
+	r.Missing += other.Missing
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// Summary counts the differences and lists the regions they fall in.
+func (d WorldDiff) Summary(context int) string {
+	if d.Empty() {
+		return fmt.Sprintf("The %vx%v worlds are the same\n", d.Width, d.Height)
+	}
+	regions := d.Regions(context)
+	var output []string
+	output = append(output, fmt.Sprintf("%v cells differ on a %vx%v world: %v alive that should be dead, %v dead that should be alive\n",
+		len(d.Extra)+len(d.Missing), d.Width, d.Height, len(d.Extra), len(d.Missing)))
+	output = append(output, fmt.Sprintf("%v regions differ:\n", len(regions)))
+	for i, r := range regions {
+		if i == diffListed {
+			output = append(output, fmt.Sprintf("  ... and %v more\n", len(regions)-diffListed))
+			break
+		}
+		output = append(output, fmt.Sprintf("  (%v, %v) to (%v, %v): %v extra, %v missing\n", r.MinX, r.MinY, r.MaxX, r.MaxY, r.Extra, r.Missing))
+	}
+	return strings.Join(output, "")
+}
+
+// Render draws each region around the differences, at most maxRegions of them. Cells alive in both worlds
+// are drawn as ██, extra cells as ++ and missing cells as --, or in red and green if colour is set.
+func (d WorldDiff) Render(got, expected [][]uint8, context, maxRegions int, colour bool) string {
+	extra, missing := "++", "--"
+	if colour {
+		extra, missing = diffRed+"██"+diffReset, diffGreen+"██"+diffReset
+	}
+	regions := d.Regions(context)
+	var output []string
+	output = append(output, fmt.Sprintf("██ alive in both   %v alive, should be dead   %v dead, should be alive\n", extra, missing))
+	for i, r := range regions {
+		if i == maxRegions {
+			output = append(output, fmt.Sprintf("... and %v more regions\n", len(regions)-maxRegions))
+			break
+		}
+		maxX, maxY := minInt(r.MaxX, r.MinX+diffMaxRows-1), minInt(r.MaxY, r.MinY+diffMaxRows-1)
+		output = append(output, fmt.Sprintf("\nRegion (%v, %v) to (%v, %v):\n", r.MinX, r.MinY, r.MaxX, r.MaxY))
+		output = append(output, fmt.Sprintf("%6v┌%v┐\n", "", strings.Repeat("─", 2*(maxX-r.MinX+1))))
+		for y := r.MinY; y <= maxY; y++ {
+			output = append(output, fmt.Sprintf("%6v│", y))
+			for x := r.MinX; x <= maxX; x++ {
+				isAlive, wantAlive := cellAlive(got, x, y), cellAlive(expected, x, y)
+				switch {
+				case isAlive && wantAlive:
+					output = append(output, "██")
+				case isAlive:
+					output = append(output, extra)
+				case wantAlive:
+					output = append(output, missing)
+				default:
+					output = append(output, "  ")
+				}
+			}
+			output = append(output, "│\n")
+		}
+		output = append(output, fmt.Sprintf("%6v└%v┘\n", "", strings.Repeat("─", 2*(maxX-r.MinX+1))))
+		if maxX < r.MaxX || maxY < r.MaxY {
+			output = append(output, fmt.Sprintf("%6vonly the first %vx%v cells are shown\n", "", maxX-r.MinX+1, maxY-r.MinY+1))
+		}
+	}
+	return strings.Join(output, "")
+}
+
+// WritePng draws both worlds at once with scale x scale pixels per cell: cells alive in both are white,
+// extra cells red, missing cells green and the rest black.
+func (d WorldDiff) WritePng(path string, got, expected [][]uint8, scale int) error {
+	if scale < 1 {
+		scale = 1
+	}
+	img := image.NewRGBA(image.Rect(0, 0, d.Width*scale, d.Height*scale))
+	for y := 0; y < d.Height; y++ {
+		for x := 0; x < d.Width; x++ {
+			isAlive, wantAlive := cellAlive(got, x, y), cellAlive(expected, x, y)
+			c := color.RGBA{A: 255}
+			switch {
+			case isAlive && wantAlive:
+				c = color.RGBA{R: 255, G: 255, B: 255, A: 255}
+			case isAlive:
+				c = color.RGBA{R: 255, A: 255}
+			case wantAlive:
+				c = color.RGBA{G: 255, A: 255}
+			}
+			for py := y * scale; py < (y+1)*scale; py++ {
+				for px := x * scale; px < (x+1)*scale; px++ {
+					img.SetRGBA(px, py, c)
+				}
+			}
+		}
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = png.Encode(file, img)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
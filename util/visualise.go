@@ -106,3 +106,51 @@ func squaresToStrings(given, expected [][]uint8, width, height int) []string {
 
 	return output
 }
+
+// quadrants maps the four corners of a character cell to a Unicode block.
+// Bit 0 is top-left, bit 1 top-right, bit 2 bottom-left and bit 3 bottom-right.
+var quadrants = []rune(" ▘▝▀▖▌▞▛▗▚▐▜▄▙▟█")
+
+// MatrixToBlocks renders a world in at most cols x rows characters using Unicode quadrant blocks.
+// Each character shows a 2x2 group of regions; a region covers several cells when the world is
+// too big to fit, and is drawn if any of its cells is alive. At least one character is always drawn
+// in each direction, however small cols and rows are.
+func MatrixToBlocks(given [][]uint8, width, height, cols, rows int) string {
+	if cols < 1 {
+		cols = 1
+	}
+	if rows < 1 {
+		rows = 1
+	}
+	scale := 1
+	for (width+2*scale-1)/(2*scale) > cols || (height+2*scale-1)/(2*scale) > rows {
+		scale++
+	}
+	region := func(rx, ry int) bool {
+		for y := ry * scale; y < (ry+1)*scale && y < height; y++ {
+			for x := rx * scale; x < (rx+1)*scale && x < width; x++ {
+				if given[y][x] != 0 {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	var output strings.Builder
+	outCols := (width + 2*scale - 1) / (2 * scale)
+	outRows := (height + 2*scale - 1) / (2 * scale)
+	for row := 0; row < outRows; row++ {
+		for col := 0; col < outCols; col++ {
+			bits := 0
+			for corner := 0; corner < 4; corner++ {
+				if region(2*col+corner%2, 2*row+corner/2) {
+					bits |= 1 << corner
+				}
+			}
+			output.WriteRune(quadrants[bits])
+		}
+		output.WriteString("\n")
+	}
+	return output.String()
+}
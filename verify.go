@@ -0,0 +1,315 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"uk.ac.bris.cs/gameoflife/bench"
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+func verifyCommand(args []string) {
+	flags := newFlagSet("verify")
+	cfg := loadConfig(args)
+	engine := flags.String(
+		"engine",
+		"gol",
+		"Engine to check: gol for the whole distributor, or one of "+strings.Join(bench.Engines, ", ")+".")
+	threads := flags.Int(
+		"t",
+		cfg.Run.Threads,
+		"Specify the number of server threads to use. For the distributed engine this is the number of workers.")
+	sizes := flags.String(
+		"sizes",
+		"16,64,512",
+		"Comma-separated board sizes to check.")
+	turns := flags.String(
+		"turns",
+		"0,1,100",
+		"Comma-separated numbers of turns to check.")
+	golden := flags.String(
+		"golden",
+		"check",
+		"Directory holding the golden images/<w>x<h>x<turns>.pgm and alive/<w>x<h>.csv files.")
+	aliveTurns := flags.Int(
+		"alive",
+		100,
+		"Check the number of alive cells after each of this many turns against alive/<w>x<h>.csv, where there is one. 0 disables it.")
+	input := flags.String(
+		"in",
+		"",
+		"Check a run of this pattern against -expect instead of using the golden directory. -turns must be a single number.")
+	expect := flags.String(
+		"expect",
+		"",
+		"Pattern the run of -in should end with.")
+	diffSize := flags.Int(
+		"diffsize",
+		64,
+		"Differences on boards up to this wide and high are drawn cell by cell; bigger boards get a summary.")
+	config := bench.DefaultConfig()
+	flags.StringVar(
+		&config.Worker,
+		"worker",
+		"",
+		"Address of the worker for the rpc engine. By default one is started in this process.")
+	flags.StringVar(
+		&config.Broker,
+		"broker",
+		"",
+		"Address of the broker for the distributed engine. By default one is started in this process.")
+	flags.Parse(args)
+
+	turnList, err := parseInts(*turns)
+	util.Check(err)
+	v, err := newVerifier(config, *engine, *threads)
+	util.Check(err)
+	defer v.close()
+
+	failed := 0
+	if *input != "" {
+		if *expect == "" || len(turnList) != 1 {
+			util.Check(fmt.Errorf("-in needs -expect and a single number of turns"))
+		}
+		world, err := gol.ReadPattern(*input)
+		util.Check(err)
+		expected, err := gol.ReadPattern(*expect)
+		util.Check(err)
+		got, _, err := v.run(world, turnList[0], false)
+		util.Check(err)
+		if !compareWorlds(fmt.Sprintf("%v after %v", filepath.Base(*input), turnList[0]), got, expected, *expect, *diffSize) {
+			failed++
+		}
+	} else {
+		sizeList, err := parseInts(*sizes)
+		util.Check(err)
+		for _, size := range sizeList {
+			world, err := gol.ReadPgm(filepath.Join(cfg.Input, fmt.Sprintf("%vx%v.pgm", size, size)))
+			util.Check(err)
+			for _, turn := range turnList {
+				name := fmt.Sprintf("%vx%vx%v", size, size, turn)
+				path := filepath.Join(*golden, "images", name+".pgm")
+				expected, err := gol.ReadPgm(path)
+				if os.IsNotExist(err) {
+					fmt.Printf("skip %-12v no golden image\n", name)
+					continue
+				}
+				util.Check(err)
+				got, _, err := v.run(world, turn, false)
+				util.Check(err)
+				if !compareWorlds(name, got, expected, path, *diffSize) {
+					failed++
+				}
+			}
+
+			path := filepath.Join(*golden, "alive", fmt.Sprintf("%vx%v.csv", size, size))
+			expected, err := readPopulations(path)
+			if os.IsNotExist(err) || *aliveTurns == 0 {
+				continue
+			}
+			util.Check(err)
+			_, populations, err := v.run(world, *aliveTurns, true)
+			util.Check(err)
+			if !comparePopulations(fmt.Sprintf("%vx%v", size, size), populations, expected, path) {
+				failed++
+			}
+		}
+	}
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// verifier runs the engine being checked.
+type verifier struct {
+	threads int
+	engine  bench.Engine // nil for the whole distributor
+}
+
+func newVerifier(config bench.Config, name string, threads int) (verifier, error) {
+	v := verifier{threads: threads}
+	if name == "gol" {
+		return v, nil
+	}
+	var err error
+	v.engine, err = bench.NewEngine(config, name, threads)
+	return v, err
+}
+
+func (v verifier) close() {
+	if v.engine != nil {
+		v.engine.Close()
+	}
+}
+
+// run returns the world after turns turns and, if asked for, the number of alive cells after each of them.
+func (v verifier) run(world [][]byte, turns int, populations bool) ([][]byte, []int, error) {
+	if v.engine == nil {
+		dir, err := os.MkdirTemp("", "verify")
+		if err != nil {
+			return nil, nil, err
+		}
+		defer os.RemoveAll(dir)
+		p := gol.Params{Turns: turns, Threads: v.threads}
+		if populations {
+			p.StatsEvery = 1
+		}
+		run, err := runDistributor(world, p, dir)
+		if err != nil {
+			return nil, nil, err
+		}
+		final := make([][]byte, len(world))
+		for y := range final {
+			final[y] = make([]byte, len(world[0]))
+		}
+		for _, cell := range run.alive {
+			final[cell.Y][cell.X] = 255
+		}
+		return final, run.counts, nil
+	}
+	if !populations {
+		world, err := v.engine.Run(world, turns)
+		return world, nil, err
+	}
+	counts := make([]int, 0, turns)
+	for turn := 0; turn < turns; turn++ {
+		var err error
+		world, err = v.engine.Run(world, 1)
+		if err != nil {
+			return nil, nil, err
+		}
+		counts = append(counts, len(aliveCells(world)))
+	}
+	return world, counts, nil
+}
+
+// distributorRun is what runDistributor sees of a run.
+type distributorRun struct {
+	alive  []util.Cell // alive at the end, from FinalTurnComplete
+	counts []int       // alive each time PopulationStats was sent
+	saved  string      // path of the last image saved
+}
+
+// runDistributor runs world through gol.Run without a viewer. The world is written to dir for the
+// distributor to read, and images are saved there too.
+func runDistributor(world [][]byte, p gol.Params, dir string) (distributorRun, error) {
+	p, err := writeInput(world, p, dir)
+	if err != nil {
+		return distributorRun{}, err
+	}
+	events := make(chan gol.Event, 1000)
+	go gol.Run(p, events, nil)
+	return collectRun(p, events), nil
+}
+
+// writeInput writes world to dir as the image a run starts from, and returns p sized to the world,
+// reading from and saving to dir.
+func writeInput(world [][]byte, p gol.Params, dir string) (gol.Params, error) {
+	p.ImageHeight, p.ImageWidth = len(world), len(world[0])
+	p.InputDir, p.OutputDir = dir, dir
+	return p, gol.WritePgm(p.InputPath(), world)
+}
+
+// collectRun reads the events of a run with p until they are closed.
+func collectRun(p gol.Params, events <-chan gol.Event) distributorRun {
+	var run distributorRun
+	for event := range events {
+		switch e := event.(type) {
+		case gol.PopulationStats:
+			run.counts = append(run.counts, e.Alive)
+		case gol.FinalTurnComplete:
+			run.alive = e.Alive
+		case gol.ImageOutputComplete:
+			run.saved = filepath.Join(p.OutputDir, e.Filename+".pgm")
+		}
+	}
+	return run
+}
+
+// aliveCells lists the cells of a world that are alive.
+func aliveCells(world [][]byte) []util.Cell {
+	var cells []util.Cell
+	for y := range world {
+		for x := range world[y] {
+			if world[y][x] != 0 {
+				cells = append(cells, util.Cell{X: x, Y: y})
+			}
+		}
+	}
+	return cells
+}
+
+// compareWorlds prints whether got matches the golden world. Differences on boards up to diffSize
+// wide and high are drawn cell by cell, and summarised on bigger ones.
+func compareWorlds(name string, got, expected [][]byte, golden string, diffSize int) bool {
+	height, width := len(expected), len(expected[0])
+	if len(got) != height || len(got[0]) != width {
+		fmt.Printf("FAIL %-12v world is %vx%v, %v is %vx%v\n", name, len(got[0]), len(got), golden, width, height)
+		return false
+	}
+	d := util.DiffWorlds(got, expected)
+	if d.Empty() {
+		fmt.Printf("ok   %v\n", name)
+		return true
+	}
+
+	fmt.Printf("FAIL %-12v %v cells differ from %v\n", name, len(d.Extra)+len(d.Missing), golden)
+	if width <= diffSize && height <= diffSize {
+		fmt.Print(util.AliveCellsToString(aliveCells(got), aliveCells(expected), width, height))
+	} else {
+		fmt.Print(d.Summary(2))
+		fmt.Print(d.Render(got, expected, 2, 3, isTerminal(os.Stdout)))
+	}
+	return false
+}
+
+// comparePopulations prints whether the number of alive cells after each turn matches the golden counts.
+func comparePopulations(name string, got []int, expected map[int]int, golden string) bool {
+	for i, count := range got {
+		turn := i + 1
+		want, ok := expected[turn]
+		if !ok {
+			break
+		}
+		if count != want {
+			fmt.Printf("FAIL %-12v %v alive after turn %v, %v has %v\n", name, count, turn, golden, want)
+			return false
+		}
+	}
+	fmt.Printf("ok   %v alive cells for %v turns\n", name, len(got))
+	return true
+}
+
+// readPopulations reads a golden CSV of completed_turns,alive_cells rows.
+func readPopulations(path string) (map[int]int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	table, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", path, err)
+	}
+	populations := make(map[int]int)
+	for i, row := range table {
+		if i == 0 {
+			continue
+		}
+		turn, err := strconv.Atoi(row[0])
+		if err != nil {
+			return nil, fmt.Errorf("%v: %v", path, err)
+		}
+		count, err := strconv.Atoi(row[1])
+		if err != nil {
+			return nil, fmt.Errorf("%v: %v", path, err)
+		}
+		populations[turn] = count
+	}
+	return populations, nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"testing"
+
+	"uk.ac.bris.cs/gameoflife/bench"
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// TestVerify checks the 16x16 image against its golden files with every engine verify can run,
+// that a single wrong cell in the output is caught, and that boards need not be square.
+func TestVerify(t *testing.T) {
+	world, err := gol.ReadPgm("images/16x16.pgm")
+	util.Check(err)
+	expected, err := gol.ReadPgm("check/images/16x16x100.pgm")
+	util.Check(err)
+	populations, err := readPopulations("check/alive/16x16.csv")
+	util.Check(err)
+
+	var distributed [][]byte
+	for _, engine := range append([]string{"gol"}, bench.Engines...) {
+		v, err := newVerifier(bench.DefaultConfig(), engine, 2)
+		util.Check(err)
+		got, counts, err := v.run(world, 100, true)
+		util.Check(err)
+		v.close()
+		if engine == "gol" {
+			distributed = got
+		}
+		assert(t, compareWorlds(engine, got, expected, "16x16x100", 64), "%v engine does not match the golden image\n", engine)
+		assert(t, len(counts) == 100 && comparePopulations(engine, counts, populations, "16x16.csv"),
+			"%v engine gave %v alive counts that do not match the golden CSV\n", engine, len(counts))
+	}
+
+	broken := make([][]byte, len(expected))
+	for y := range expected {
+		broken[y] = append([]byte(nil), expected[y]...)
+	}
+	broken[0][0] = 255 - broken[0][0]
+	d := util.DiffWorlds(distributed, broken)
+	assert(t, len(d.Extra)+len(d.Missing) == 1, "Broken image differs in %v cells, expected 1\n", len(d.Extra)+len(d.Missing))
+	assert(t, !compareWorlds("broken", distributed, broken, "16x16x100", 64), "A wrong cell was not reported\n")
+
+	// The top half of the 16x16 image, run through the distributor and checked against NextWorld.
+	wide := world[:8]
+	v, err := newVerifier(bench.DefaultConfig(), "gol", 2)
+	util.Check(err)
+	defer v.close()
+	got, _, err := v.run(wide, 10, false)
+	util.Check(err)
+	want := wide
+	for turn := 0; turn < 10; turn++ {
+		want = gol.NextWorld(want, gol.Params{Threads: 2, ImageWidth: 16, ImageHeight: 8})
+	}
+	assert(t, compareWorlds("16x8", got, want, "NextWorld", 64), "16x8 run does not match NextWorld\n")
+}
@@ -0,0 +1,291 @@
+package web
+
+import (
+	_ "embed"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+//go:embed viewer.html
+var viewerHTML []byte
+
+// Binary message types. All integers are little-endian uint32.
+//
+//	snapshot: [1] turn width height count (x y)*count   the whole board, sent when a viewer connects
+//	flips:    [2] turn count (x y)*count                cells that changed since the previous message
+const (
+	snapshotMessage = 1
+	flipsMessage    = 2
+)
+
+// clientBuffer is the number of messages queued for a viewer before it is considered too slow
+// and disconnected. The page reconnects and starts again from a fresh snapshot.
+const clientBuffer = 256
+
+type message struct {
+	opcode  byte
+	payload []byte
+}
+
+// jsonMessage is sent as a text frame for every event other than cell flips.
+type jsonMessage struct {
+	Type     string `json:"type"`
+	Turn     int    `json:"turn"`
+	Alive    int    `json:"alive,omitempty"`
+	State    string `json:"state,omitempty"`
+	Filename string `json:"filename,omitempty"`
+	Message  string `json:"message,omitempty"`
+}
+
+type client struct {
+	conn *conn
+	send chan message
+}
+
+// hub keeps the board as last sent to viewers, so that a new viewer can be sent a snapshot
+// that the following flips apply to.
+type hub struct {
+	mutex      sync.Mutex
+	clients    map[*client]bool
+	width      int
+	height     int
+	world      [][]bool
+	turn       int
+	pending    []util.Cell
+	keyPresses chan<- rune
+}
+
+// Run serves a browser viewer on addr and streams events to it over a WebSocket.
+// Keys pressed in the page are forwarded to keyPresses. It is a peer of sdl.Run for
+// teammates without SDL installed, and returns when the events channel is closed or quitting.
+func Run(p gol.Params, events <-chan gol.Event, keyPresses chan<- rune, addr string) {
+	listener, err := net.Listen("tcp", addr)
+	util.Check(err)
+	fmt.Printf("Viewer available at http://%v/\n", listener.Addr())
+	Serve(p, events, keyPresses, listener)
+}
+
+// Serve is like Run but accepts viewers on an existing listener, which it closes before returning.
+func Serve(p gol.Params, events <-chan gol.Event, keyPresses chan<- rune, listener net.Listener) {
+	h := &hub{
+		clients:    make(map[*client]bool),
+		width:      p.ImageWidth,
+		height:     p.ImageHeight,
+		keyPresses: keyPresses,
+	}
+	h.world = make([][]bool, p.ImageHeight)
+	for i := range h.world {
+		h.world[i] = make([]bool, p.ImageWidth)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write(viewerHTML)
+	})
+	mux.HandleFunc("/ws", h.serveWebsocket)
+
+	server := &http.Server{Handler: mux}
+	go func() { _ = server.Serve(listener) }()
+
+	h.handleEvents(events)
+
+	_ = server.Close()
+	h.closeAll()
+}
+
+// handleEvents forwards events to every connected viewer until quitting.
+func (h *hub) handleEvents(events <-chan gol.Event) {
+	for event := range events {
+		switch e := event.(type) {
+		case gol.CellFlipped:
+			h.mutex.Lock()
+			h.pending = append(h.pending, e.Cell)
+			h.mutex.Unlock()
+			continue
+		case gol.CellsFlipped:
+			h.mutex.Lock()
+			h.pending = append(h.pending, e.Cells...)
+			h.mutex.Unlock()
+			continue
+		}
+
+		h.flush(event.GetCompletedTurns())
+		msg := jsonMessage{Type: eventType(event), Turn: event.GetCompletedTurns()}
+		switch e := event.(type) {
+		case gol.AliveCellsCount:
+			msg.Alive = e.CellsCount
+		case gol.FinalTurnComplete:
+			msg.Alive = len(e.Alive)
+		case gol.StateChange:
+			msg.State = e.NewState.String()
+		case gol.ImageOutputComplete:
+			msg.Filename = e.Filename
+		case gol.BreakpointHit:
+			msg.Message = e.String()
+		}
+		payload, err := json.Marshal(msg)
+		util.Check(err)
+		h.broadcast(message{opText, payload})
+
+		if e, ok := event.(gol.StateChange); ok && e.NewState == gol.Quitting {
+			return
+		}
+	}
+}
+
+// flush applies the pending flips to the hub's board and sends them to every viewer as one message.
+func (h *hub) flush(turn int) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.turn = turn
+	if len(h.pending) == 0 {
+		return
+	}
+	for _, cell := range h.pending {
+		h.world[cell.Y][cell.X] = !h.world[cell.Y][cell.X]
+	}
+	payload := encodeCells(flipsMessage, []uint32{uint32(turn)}, h.pending)
+	h.pending = h.pending[:0]
+	h.broadcastLocked(message{opBinary, payload})
+}
+
+func (h *hub) broadcast(msg message) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.broadcastLocked(msg)
+}
+
+// broadcastLocked queues msg for every viewer, dropping any viewer that has fallen too far behind.
+func (h *hub) broadcastLocked(msg message) {
+	for c := range h.clients {
+		select {
+		case c.send <- msg:
+		default:
+			delete(h.clients, c)
+			close(c.send)
+		}
+	}
+}
+
+// snapshotLocked encodes every live cell on the hub's board.
+func (h *hub) snapshotLocked() []byte {
+	var alive []util.Cell
+	for y, row := range h.world {
+		for x, cell := range row {
+			if cell {
+				alive = append(alive, util.Cell{X: x, Y: y})
+			}
+		}
+	}
+	header := []uint32{uint32(h.turn), uint32(h.width), uint32(h.height)}
+	return encodeCells(snapshotMessage, header, alive)
+}
+
+func (h *hub) serveWebsocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrade(w, r)
+	if err != nil {
+		return
+	}
+	c := &client{conn: conn, send: make(chan message, clientBuffer)}
+	h.mutex.Lock()
+	c.send <- message{opBinary, h.snapshotLocked()}
+	h.clients[c] = true
+	h.mutex.Unlock()
+
+	go c.writeLoop()
+	c.readLoop(h.keyPresses)
+	h.remove(c)
+}
+
+func (h *hub) remove(c *client) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if h.clients[c] {
+		delete(h.clients, c)
+		close(c.send)
+	}
+}
+
+func (h *hub) closeAll() {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	for c := range h.clients {
+		delete(h.clients, c)
+		close(c.send)
+	}
+}
+
+// writeLoop sends queued messages until the hub closes the queue, then closes the connection.
+func (c *client) writeLoop() {
+	defer c.conn.Close()
+	for msg := range c.send {
+		if err := c.conn.writeFrame(msg.opcode, msg.payload); err != nil {
+			return
+		}
+	}
+	_ = c.conn.writeFrame(opClose, nil)
+}
+
+// readLoop forwards keypresses sent by the page as single-character text messages.
+func (c *client) readLoop(keyPresses chan<- rune) {
+	for {
+		opcode, payload, err := c.conn.readMessage()
+		if err != nil {
+			return
+		}
+		if opcode != opText || len(payload) != 1 {
+			continue
+		}
+		switch key := rune(payload[0]); key {
+		case 'p', 's', 'q', 'k':
+			keyPresses <- key
+		}
+	}
+}
+
+func encodeCells(kind byte, header []uint32, cells []util.Cell) []byte {
+	payload := make([]byte, 1, 1+4*(len(header)+1)+8*len(cells))
+	payload[0] = kind
+	for _, value := range header {
+		payload = appendUint32(payload, value)
+	}
+	payload = appendUint32(payload, uint32(len(cells)))
+	for _, cell := range cells {
+		payload = appendUint32(payload, uint32(cell.X))
+		payload = appendUint32(payload, uint32(cell.Y))
+	}
+	return payload
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], v)
+	return append(b, buf[:]...)
+}
+
+func eventType(event gol.Event) string {
+	switch event.(type) {
+	case gol.TurnComplete:
+		return "TurnComplete"
+	case gol.AliveCellsCount:
+		return "AliveCellsCount"
+	case gol.FinalTurnComplete:
+		return "FinalTurnComplete"
+	case gol.StateChange:
+		return "StateChange"
+	case gol.ImageOutputComplete:
+		return "ImageOutputComplete"
+	case gol.BreakpointHit:
+		return "BreakpointHit"
+	default:
+		return fmt.Sprintf("%T", event)
+	}
+}
@@ -0,0 +1,223 @@
+package web
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// This is a minimal WebSocket (RFC 6455) implementation, enough to stream frames to a browser
+// and receive short messages back without pulling in a third-party module.
+
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// Opcodes for WebSocket frames.
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xA
+)
+
+// maxMessageSize limits the frames accepted from clients, which only ever send keypresses.
+const maxMessageSize = 1 << 16
+
+var errNotWebsocket = errors.New("not a websocket handshake")
+
+// conn is a server-side WebSocket connection.
+// Writes are serialised by a mutex so pongs and messages can be sent from different goroutines.
+type conn struct {
+	netConn net.Conn
+	reader  *bufio.Reader
+	mutex   sync.Mutex
+}
+
+// upgrade completes the WebSocket handshake and takes over the underlying connection.
+// Browsers send the Origin of the page opening the WebSocket, and only the viewer's own page may, so that
+// other sites open in the same browser cannot watch the board or press keys.
+func upgrade(w http.ResponseWriter, r *http.Request) (*conn, error) {
+	if !headerContains(r.Header, "Connection", "upgrade") || !headerContains(r.Header, "Upgrade", "websocket") {
+		http.Error(w, "expected a websocket upgrade", http.StatusBadRequest)
+		return nil, errNotWebsocket
+	}
+	if !sameOrigin(r) {
+		http.Error(w, "origin not allowed", http.StatusForbidden)
+		return nil, errNotWebsocket
+	}
+	key := r.Header.Get("Sec-Websocket-Key")
+	if key == "" {
+		http.Error(w, "missing Sec-WebSocket-Key", http.StatusBadRequest)
+		return nil, errNotWebsocket
+	}
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "websocket not supported", http.StatusInternalServerError)
+		return nil, errNotWebsocket
+	}
+	netConn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, err
+	}
+	_, err = rw.WriteString("HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + acceptKey(key) + "\r\n\r\n")
+	if err == nil {
+		err = rw.Flush()
+	}
+	if err != nil {
+		netConn.Close()
+		return nil, err
+	}
+	return &conn{netConn: netConn, reader: rw.Reader}, nil
+}
+
+// acceptKey computes the Sec-WebSocket-Accept value for a client's key.
+func acceptKey(key string) string {
+	hash := sha1.Sum([]byte(key + websocketGUID))
+	return base64.StdEncoding.EncodeToString(hash[:])
+}
+
+// sameOrigin reports whether the Origin of a request, if it has one, is the host it was sent to.
+// Clients other than browsers, such as the tests, send no Origin.
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+func headerContains(header http.Header, name, value string) bool {
+	for _, v := range header.Values(name) {
+		for _, token := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(token), value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// writeFrame sends a single unfragmented frame. Server frames are never masked.
+func (c *conn) writeFrame(opcode byte, payload []byte) error {
+	header := make([]byte, 2, 10)
+	header[0] = 0x80 | opcode
+	switch length := len(payload); {
+	case length < 126:
+		header[1] = byte(length)
+	case length <= 0xFFFF:
+		header[1] = 126
+		header = append(header, 0, 0)
+		binary.BigEndian.PutUint16(header[2:], uint16(length))
+	default:
+		header[1] = 127
+		header = append(header, make([]byte, 8)...)
+		binary.BigEndian.PutUint64(header[2:], uint64(length))
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if _, err := c.netConn.Write(header); err != nil {
+		return err
+	}
+	_, err := c.netConn.Write(payload)
+	return err
+}
+
+// readMessage returns the next text or binary message, answering pings along the way.
+// It returns io.EOF once the client closes the connection.
+func (c *conn) readMessage() (opcode byte, payload []byte, err error) {
+	for {
+		fin, op, data, err := c.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+		switch op {
+		case opClose:
+			_ = c.writeFrame(opClose, nil)
+			return 0, nil, io.EOF
+		case opPing:
+			if err := c.writeFrame(opPong, data); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case opPong:
+			continue
+		case opContinuation:
+			if opcode == 0 {
+				return 0, nil, errors.New("unexpected continuation frame")
+			}
+		default:
+			opcode = op
+			payload = nil
+		}
+		payload = append(payload, data...)
+		if len(payload) > maxMessageSize {
+			return 0, nil, errors.New("message too large")
+		}
+		if fin {
+			return opcode, payload, nil
+		}
+	}
+}
+
+// readFrame reads one frame from the client and unmasks its payload.
+func (c *conn) readFrame() (fin bool, opcode byte, payload []byte, err error) {
+	var header [2]byte
+	if _, err = io.ReadFull(c.reader, header[:]); err != nil {
+		return
+	}
+	fin = header[0]&0x80 != 0
+	opcode = header[0] & 0x0F
+	masked := header[1]&0x80 != 0
+	length := uint64(header[1] & 0x7F)
+	switch length {
+	case 126:
+		var extended [2]byte
+		if _, err = io.ReadFull(c.reader, extended[:]); err != nil {
+			return
+		}
+		length = uint64(binary.BigEndian.Uint16(extended[:]))
+	case 127:
+		var extended [8]byte
+		if _, err = io.ReadFull(c.reader, extended[:]); err != nil {
+			return
+		}
+		length = binary.BigEndian.Uint64(extended[:])
+	}
+	if length > maxMessageSize {
+		err = errors.New("frame too large")
+		return
+	}
+	var mask [4]byte
+	if masked {
+		if _, err = io.ReadFull(c.reader, mask[:]); err != nil {
+			return
+		}
+	}
+	payload = make([]byte, length)
+	if _, err = io.ReadFull(c.reader, payload); err != nil {
+		return
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return
+}
+
+func (c *conn) Close() error {
+	return c.netConn.Close()
+}
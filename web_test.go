@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/util"
+	"uk.ac.bris.cs/gameoflife/web"
+)
+
+// TestWeb connects to the browser viewer with a minimal WebSocket client and checks that
+// flips, turns and keypresses are passed through, and that pages on other sites cannot connect.
+func TestWeb(t *testing.T) {
+	params := gol.Params{ImageWidth: 16, ImageHeight: 16}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	util.Check(err)
+	events := make(chan gol.Event)
+	keyPresses := make(chan rune, 1)
+	webDone := make(chan bool)
+	go func() {
+		web.Serve(params, events, keyPresses, listener)
+		webDone <- true
+	}()
+
+	addr := listener.Addr().String()
+	other, _, response := websocketHandshake(addr, "http://example.com")
+	other.Close()
+	assert(t, response.StatusCode == http.StatusForbidden, "Handshake from another origin got status %v, expected 403\n",
+		response.Status)
+
+	conn, reader := dialWebsocket(t, addr, "http://"+addr)
+	defer conn.Close()
+
+	opcode, payload := readWebsocketFrame(t, reader)
+	assert(t, opcode == 0x2 && payload[0] == 1, "Expected a binary snapshot first, got opcode %v\n", opcode)
+	width := binary.LittleEndian.Uint32(payload[5:])
+	height := binary.LittleEndian.Uint32(payload[9:])
+	assert(t, width == 16 && height == 16, "Snapshot is %vx%v, expected 16x16\n", width, height)
+
+	events <- gol.CellsFlipped{CompletedTurns: 1, Cells: []util.Cell{{X: 3, Y: 4}, {X: 5, Y: 6}}}
+	events <- gol.TurnComplete{CompletedTurns: 1}
+
+	opcode, payload = readWebsocketFrame(t, reader)
+	assert(t, opcode == 0x2 && payload[0] == 2, "Expected a binary flips message, got opcode %v\n", opcode)
+	count := binary.LittleEndian.Uint32(payload[5:])
+	assert(t, count == 2, "Expected 2 flipped cells, got %v\n", count)
+	x := binary.LittleEndian.Uint32(payload[9:])
+	y := binary.LittleEndian.Uint32(payload[13:])
+	assert(t, x == 3 && y == 4, "Expected first flip at (3, 4), got (%v, %v)\n", x, y)
+
+	opcode, payload = readWebsocketFrame(t, reader)
+	var msg struct {
+		Type string
+		Turn int
+	}
+	util.Check(json.Unmarshal(payload, &msg))
+	assert(t, opcode == 0x1 && msg.Type == "TurnComplete" && msg.Turn == 1,
+		"Expected TurnComplete for turn 1, got %v\n", string(payload))
+
+	writeWebsocketText(t, conn, "p")
+	select {
+	case key := <-keyPresses:
+		assert(t, key == 'p', "Expected keypress p, got %c\n", key)
+	case <-time.After(2 * time.Second):
+		t.Error("ERROR: Keypress from the viewer was not forwarded")
+	}
+
+	close(events)
+	select {
+	case <-webDone:
+	case <-time.After(2 * time.Second):
+		t.Error("ERROR: web.Serve did not return after the events channel was closed")
+	}
+}
+
+// websocketHandshake asks the viewer on addr to upgrade to a WebSocket, as a page from origin would,
+// and returns the connection and the response.
+func websocketHandshake(addr, origin string) (net.Conn, *bufio.Reader, *http.Response) {
+	conn, err := net.Dial("tcp", addr)
+	util.Check(err)
+	key := make([]byte, 16)
+	_, _ = rand.Read(key)
+	_, err = conn.Write([]byte("GET /ws HTTP/1.1\r\nHost: " + addr + "\r\nOrigin: " + origin + "\r\n" +
+		"Upgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Version: 13\r\n" +
+		"Sec-WebSocket-Key: " + base64.StdEncoding.EncodeToString(key) + "\r\n\r\n"))
+	util.Check(err)
+	reader := bufio.NewReader(conn)
+	response, err := http.ReadResponse(reader, nil)
+	util.Check(err)
+	return conn, reader, response
+}
+
+func dialWebsocket(t *testing.T, addr, origin string) (net.Conn, *bufio.Reader) {
+	conn, reader, response := websocketHandshake(addr, origin)
+	if response.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("ERROR: Websocket handshake failed with status %v", response.Status)
+	}
+	return conn, reader
+}
+
+func readWebsocketFrame(t *testing.T, reader *bufio.Reader) (byte, []byte) {
+	var header [2]byte
+	_, err := io.ReadFull(reader, header[:])
+	util.Check(err)
+	length := uint64(header[1] & 0x7F)
+	switch length {
+	case 126:
+		var extended [2]byte
+		_, err = io.ReadFull(reader, extended[:])
+		length = uint64(binary.BigEndian.Uint16(extended[:]))
+	case 127:
+		var extended [8]byte
+		_, err = io.ReadFull(reader, extended[:])
+		length = binary.BigEndian.Uint64(extended[:])
+	}
+	util.Check(err)
+	payload := make([]byte, length)
+	_, err = io.ReadFull(reader, payload)
+	util.Check(err)
+	return header[0] & 0x0F, payload
+}
+
+func writeWebsocketText(t *testing.T, conn net.Conn, text string) {
+	mask := []byte{1, 2, 3, 4}
+	frame := []byte{0x81, 0x80 | byte(len(text))}
+	frame = append(frame, mask...)
+	for i := range text {
+		frame = append(frame, text[i]^mask[i%4])
+	}
+	_, err := conn.Write(frame)
+	util.Check(err)
+}
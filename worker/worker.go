@@ -0,0 +1,39 @@
+package worker
+
+import (
+	"fmt"
+	"net"
+	"net/rpc"
+	"time"
+
+	"uk.ac.bris.cs/gameoflife/gol"
+	"uk.ac.bris.cs/gameoflife/stubs"
+	"uk.ac.bris.cs/gameoflife/util"
+)
+
+// Worker computes strips of the next turn for a broker.
+type Worker struct{}
+
+// NextStrip computes the rows asked for in req.
+func (w *Worker) NextStrip(req stubs.StripRequest, res *stubs.StripResponse) error {
+	if req.StartY < 0 || req.StartY > req.EndY || req.EndY > len(req.World) {
+		return fmt.Errorf("rows %v to %v are outside a world of height %v", req.StartY, req.EndY, len(req.World))
+	}
+	start := time.Now()
+	res.Rows = gol.NextRows(req.World, req.StartY, req.EndY)
+	gol.ObserveStep(0, time.Since(start))
+	return nil
+}
+
+// Serve registers a Worker and answers RPC calls on listener until it is closed.
+func Serve(listener net.Listener) {
+	server := rpc.NewServer()
+	util.Check(server.Register(&Worker{}))
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		go server.ServeConn(conn)
+	}
+}